@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/service"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage: apikey [-config file] <command> [options]
+
+Commands:
+  create -name NAME [-scopes read,write,admin] [-tenant ID]  create a key and print it once
+  list                                                       list keys without secrets
+  revoke -name NAME                                          revoke a key
+
+Database settings are read like the server's: config file, then POSTGRES_* variables.
+`
+
+func main() {
+	global := flag.NewFlagSet("apikey", flag.ExitOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	configFile := global.String("config", "", "path to the YAML config file")
+	global.Parse(os.Args[1:])
+
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+
+	var configArgs []string
+	if *configFile != "" {
+		configArgs = []string{"-config", *configFile}
+	}
+	cfg, _, err := config.Load(configArgs)
+	if err != nil {
+		fail(err)
+	}
+
+	// Сообщения о подключении не нужны в выводе команды
+	logger, _ := logging.New(os.Stderr, "warn", logging.FormatText)
+	slog.SetDefault(logger)
+
+	if err := repository.Init(cfg); err != nil {
+		fail(err)
+	}
+	defer repository.CloseDB()
+	service.Configure(cfg)
+
+	ctx := context.Background()
+	command, args := global.Arg(0), global.Args()[1:]
+	switch command {
+	case "create":
+		err = create(ctx, args)
+	case "list":
+		err = list(ctx)
+	case "revoke":
+		err = revoke(ctx, args)
+	default:
+		global.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		repository.CloseDB()
+		fail(err)
+	}
+}
+
+func create(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	name := flags.String("name", "", "unique key name, e.g. the client service")
+	scopeList := flags.String("scopes", "read", "comma-separated scopes: read, write, admin")
+	tenantId := flags.String("tenant", "", "tenant the key is bound to; empty for a key not bound to a tenant")
+	flags.Parse(args)
+
+	scopes, err := service.ParseScopes(*scopeList)
+	if err != nil {
+		return err
+	}
+
+	token, key, err := service.CreateAPIKey(ctx, *name, *tenantId, scopes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Created key %q with scopes %s. Store it now, it cannot be shown again:\n", key.Name, joinScopes(key.Scopes))
+	fmt.Println(token)
+	return nil
+}
+
+func list(ctx context.Context) error {
+	keys, err := service.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tTENANT\tCREATED\tLAST USED\tREVOKED")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%d\t%s\t%s…\t%s\t%s\t%s\t%s\t%s\n",
+			key.Id, key.Name, key.Prefix, joinScopes(key.Scopes), orDash(key.Tenant),
+			key.CreatedAt.Format(time.RFC3339), formatTime(key.LastUsedAt), formatTime(key.RevokedAt))
+	}
+	return w.Flush()
+}
+
+func revoke(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("revoke", flag.ExitOnError)
+	name := flags.String("name", "", "name of the key to revoke")
+	flags.Parse(args)
+
+	if err := service.RevokeAPIKey(ctx, *name); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("active key %q not found", *name)
+		}
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Revoked key %q\n", *name)
+	return nil
+}
+
+func joinScopes[S ~string](scopes []S) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "apikey: %v\n", err)
+	os.Exit(1)
+}
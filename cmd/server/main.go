@@ -1,33 +1,188 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"itmo-devops-fp1/internal/auth"
+	"itmo-devops-fp1/internal/config"
 	"itmo-devops-fp1/internal/handler"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/metrics"
+	"itmo-devops-fp1/internal/ratelimit"
 	"itmo-devops-fp1/internal/repository"
-	"log"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/tracing"
+	"itmo-devops-fp1/internal/types"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// Время на отправку накопленных трасс при остановке
+const traceFlushTimeout = 5 * time.Second
+
 func main() {
-	defer repository.CloseDB()
-	log.Println("Server is starting...")
+	cfg, printOnly, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		os.Exit(2)
+	}
+	if printOnly {
+		fmt.Print(cfg)
+		return
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to configure logging: %v\n", err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	slog.Info("Server is starting", "config", cfg)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to configure tracing", "error", err)
+		os.Exit(1)
+	}
+	if err := repository.Init(cfg); err != nil {
+		slog.Error("Failed to initialize repository", "error", err)
+		os.Exit(1)
+	}
+	service.Configure(cfg)
+
+	var authenticator *auth.Authenticator
+	if cfg.Auth.APIKeys || cfg.Auth.JWT.Enabled() {
+		if authenticator, err = auth.New(cfg.Auth); err != nil {
+			repository.CloseDB()
+			slog.Error("Failed to configure authentication", "error", err)
+			os.Exit(1)
+		}
+	}
 
 	// Создаем новый роутер
 	r := chi.NewRouter()
 
 	// Добавляем middleware (опционально)
-	r.Use(middleware.Logger)
+	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(logging.Middleware)
+	r.Use(metrics.Middleware)
+
+	// Проверки работоспособности и готовности для оркестратора
+	r.Get("/healthz", handler.HealthHandler)
+	r.Get("/readyz", handler.ReadyHandler)
+	r.Handle("/metrics", metrics.Handler())
 
 	// Регистрируем маршруты
-	r.Route("/api/v0", func(r chi.Router) {
-		r.Post("/prices", handler.UploadHandler)
+	r.Route(cfg.Server.RoutePrefix, func(r chi.Router) {
+		if authenticator != nil {
+			// Неудачные проверки ограничиваются по IP-адресу до поиска ключа в базе
+			if cfg.RateLimit.RequestsPerSecond > 0 {
+				r.Use(ratelimit.New(cfg.RateLimit).Failures)
+			}
+			r.Use(authenticator.Middleware)
+		}
+		r.Use(auth.Tenants(cfg.Tenant))
+		if cfg.RateLimit.RequestsPerSecond > 0 {
+			r.Use(ratelimit.New(cfg.RateLimit).Middleware)
+		}
+		admin := r.With(auth.Require(types.ScopeAdmin))
+		// Каталог и курсы общие, поэтому их меняет только администратор без привязки к арендатору
+		global := admin.With(auth.RequireGlobal)
+
+		// Загрузки и массовые изменения делят одни места для одновременной обработки
+		heavy := ratelimit.Uploads(cfg.RateLimit)
+		r.With(heavy).Post("/prices", handler.UploadHandler)
+		r.With(heavy).Post("/prices/item", handler.CreateProductHandler)
+		admin.With(heavy).Post("/prices/dedupe", handler.DedupeHandler)
+		global.With(heavy).Post("/rates", handler.UploadRatesHandler)
+		admin.With(heavy).Post("/quarantine/{id}/promote", handler.PromoteQuarantinedHandler)
+
 		r.Get("/prices", handler.DownloadHandler)
+		r.Get("/prices/stats", handler.StatisticsHandler)
+		r.Get("/prices/search", handler.SearchHandler)
+		r.Get("/prices/duplicates", handler.DuplicatesHandler)
+		r.Get("/prices/{id}", handler.GetProductHandler)
+		r.Put("/prices/{id}", handler.ReplaceProductHandler)
+		r.Patch("/prices/{id}", handler.PatchProductHandler)
+		r.Delete("/prices/{id}", handler.DeleteProductHandler)
+
+		r.Get("/products", handler.ListProductsHandler)
+		r.Get("/products/{id}/history", handler.PriceHistoryHandler)
+
+		r.Get("/categories", handler.ListCategoriesHandler)
+		global.Post("/categories", handler.CreateCategoryHandler)
+		r.Get("/categories/{id}", handler.GetCategoryHandler)
+		global.Put("/categories/{id}", handler.UpdateCategoryHandler)
+		global.Delete("/categories/{id}", handler.DeleteCategoryHandler)
+
+		r.Get("/rates", handler.ListRatesHandler)
+
+		r.Get("/quarantine", handler.ListQuarantineHandler)
+		admin.Delete("/quarantine", handler.PurgeQuarantineHandler)
+		admin.Delete("/quarantine/{id}", handler.DeleteQuarantinedHandler)
 	})
 
-	log.Println("Server started on :8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	server := &http.Server{Addr: cfg.Server.Addr, Handler: r}
+
+	// Останавливаемся по SIGINT и SIGTERM, дождавшись текущих запросов
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server started", "addr", cfg.Server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		repository.CloseDB()
+		slog.Error("Server failed to start", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	// Повторный сигнал завершает процесс сразу, не дожидаясь запросов
+	stop()
+
+	// /readyz отвечает 503, но новые запросы еще принимаются, пока балансировщик не исключит экземпляр
+	service.SetDraining()
+	if cfg.Server.ReadinessGrace > 0 {
+		slog.Info("Draining, readiness probe is failing", "grace", cfg.Server.ReadinessGrace)
+		time.Sleep(cfg.Server.ReadinessGrace)
+	}
+
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Graceful shutdown failed, closing connections", "error", err)
+		server.Close()
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Server stopped with error", "error", err)
+	}
+
+	repository.CloseDB()
+
+	// Время на завершение запросов могло уже истечь, поэтому трассы отправляются со своим таймаутом
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
 	}
+	slog.Info("Server stopped")
 }
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"errors"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"strings"
+)
+
+// Заголовок, в котором клиент передает API-ключ
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator проверяет клиентов по API-ключам и JWT, включенным в настройках
+type Authenticator struct {
+	apiKeys bool
+	jwt     *jwtVerifier
+}
+
+// New создает проверку клиентов; ключи JWKS из файла читаются сразу
+func New(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{apiKeys: cfg.APIKeys}
+	if cfg.JWT.Enabled() {
+		verifier, err := newJWTVerifier(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = verifier
+	}
+	return a, nil
+}
+
+// Middleware пропускает только запросы с действующим API-ключом в заголовке X-API-Key
+// или JWT в заголовке Authorization: Bearer.
+// Для чтения (GET, HEAD, OPTIONS) нужно право read, для остальных методов — write
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.authenticate(r)
+		if errors.Is(err, service.ErrUnauthorized) {
+			a.unauthorized(w, err)
+			return
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Error("ошибка проверки клиента", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !checkScope(w, principal, requiredScope(r.Method)) {
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(service.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Require дополнительно требует право scope, например admin для управления каталогом.
+// Если проверка клиентов выключена, запрос пропускается
+func Require(scope types.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal, ok := service.PrincipalFromContext(r.Context()); ok && !checkScope(w, principal, scope) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireGlobal пропускает только клиентов, не привязанных к арендатору: каталог категорий
+// и курсы валют общие для всех арендаторов. Если проверка клиентов выключена, запрос пропускается
+func RequireGlobal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, ok := service.PrincipalFromContext(r.Context()); ok && principal.Tenant != "" {
+			http.Error(w, "общие данные может изменять только клиент, не привязанный к арендатору", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate определяет клиента по API-ключу или bearer-токену
+func (a *Authenticator) authenticate(r *http.Request) (types.Principal, error) {
+	if token := r.Header.Get(APIKeyHeader); token != "" && a.apiKeys {
+		return service.AuthenticateAPIKey(r.Context(), token)
+	}
+
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if strings.EqualFold(scheme, "Bearer") && token != "" && a.jwt != nil {
+		return a.jwt.verify(r.Context(), strings.TrimSpace(token))
+	}
+
+	return types.Principal{}, service.ErrUnauthorized
+}
+
+// Право, необходимое для метода запроса
+func requiredScope(method string) types.Scope {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return types.ScopeRead
+	default:
+		return types.ScopeWrite
+	}
+}
+
+// checkScope отвечает 403, если у клиента нет права scope
+func checkScope(w http.ResponseWriter, principal types.Principal, scope types.Scope) bool {
+	if service.HasScope(principal, scope) {
+		return true
+	}
+	http.Error(w, "недостаточно прав: требуется "+string(scope), http.StatusForbidden)
+	return false
+}
+
+func (a *Authenticator) unauthorized(w http.ResponseWriter, err error) {
+	if a.apiKeys {
+		w.Header().Add("WWW-Authenticate", `APIKey header="`+APIKeyHeader+`"`)
+	}
+	if a.jwt != nil {
+		w.Header().Add("WWW-Authenticate", `Bearer`)
+	}
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+}
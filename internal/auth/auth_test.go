@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequire(t *testing.T) {
+	admin := types.Principal{Subject: "apikey:admin", Scopes: []types.Scope{types.ScopeAdmin}}
+	tenantAdmin := types.Principal{Subject: "apikey:acme", Scopes: []types.Scope{types.ScopeAdmin}, Tenant: "acme"}
+	writer := types.Principal{Subject: "apikey:loader", Scopes: []types.Scope{types.ScopeRead, types.ScopeWrite}}
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		principal  *types.Principal
+		want       int
+	}{
+		{"admin_without_auth", Require(types.ScopeAdmin), nil, http.StatusOK},
+		{"admin", Require(types.ScopeAdmin), &admin, http.StatusOK},
+		{"admin_denied_for_writer", Require(types.ScopeAdmin), &writer, http.StatusForbidden},
+		{"global_without_auth", RequireGlobal, nil, http.StatusOK},
+		{"global_admin", RequireGlobal, &admin, http.StatusOK},
+		{"global_denied_for_tenant_admin", RequireGlobal, &tenantAdmin, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/api/v0/categories/1", nil)
+			if tt.principal != nil {
+				r = r.WithContext(service.WithPrincipal(r.Context(), *tt.principal))
+			}
+			w := httptest.NewRecorder()
+			tt.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("код %d, ожидался %d", w.Code, tt.want)
+			}
+		})
+	}
+}
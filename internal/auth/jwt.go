@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Допустимое расхождение часов с поставщиком удостоверений
+const clockLeeway = time.Minute
+
+// Минимальный интервал между попытками загрузить JWKS
+const jwksRetryInterval = 30 * time.Second
+
+// Предельный размер ответа с ключами JWKS
+const maxJWKSSize = 1 << 20
+
+// Алгоритмы подписи, которые принимаются от поставщика удостоверений
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// jwtVerifier проверяет подпись и claims токенов и переводит права в scopes
+type jwtVerifier struct {
+	cfg    config.JWTConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        jose.JSONWebKeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error         // ошибка последней загрузки JWKS
+	fetching    chan struct{} // закрывается по завершении текущей загрузки; nil, если загрузки нет
+}
+
+// newJWTVerifier готовит проверку токенов. Ключи из файла читаются сразу,
+// ключи по URL загружаются при первом запросе и обновляются с периодом JWKSRefresh
+func newJWTVerifier(cfg config.JWTConfig) (*jwtVerifier, error) {
+	v := &jwtVerifier{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения JWKS: %w", err)
+		}
+		if v.keys, err = parseJWKS(data); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// verify проверяет токен и возвращает клиента с правами из claim с правами
+func (v *jwtVerifier) verify(ctx context.Context, token string) (types.Principal, error) {
+	parsed, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil {
+		return types.Principal{}, fmt.Errorf("%w: %v", service.ErrUnauthorized, err)
+	}
+
+	kid := ""
+	if len(parsed.Headers) > 0 {
+		kid = parsed.Headers[0].KeyID
+	}
+	keys, err := v.lookup(ctx, kid)
+	if err != nil {
+		return types.Principal{}, err
+	}
+	if len(keys) == 0 {
+		return types.Principal{}, fmt.Errorf("%w: неизвестный ключ подписи %q", service.ErrUnauthorized, kid)
+	}
+
+	var claims jwt.Claims
+	var custom map[string]interface{}
+	for _, key := range keys {
+		if err = parsed.Claims(key.Key, &claims, &custom); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return types.Principal{}, fmt.Errorf("%w: неверная подпись", service.ErrUnauthorized)
+	}
+
+	if claims.Expiry == nil {
+		return types.Principal{}, fmt.Errorf("%w: в токене нет срока действия", service.ErrUnauthorized)
+	}
+	expected := jwt.Expected{Issuer: v.cfg.Issuer, Time: time.Now()}
+	if v.cfg.Audience != "" {
+		expected.AnyAudience = jwt.Audience{v.cfg.Audience}
+	}
+	if err := claims.ValidateWithLeeway(expected, clockLeeway); err != nil {
+		return types.Principal{}, fmt.Errorf("%w: %v", service.ErrUnauthorized, err)
+	}
+	if claims.Subject == "" {
+		return types.Principal{}, fmt.Errorf("%w: в токене нет subject", service.ErrUnauthorized)
+	}
+
+	// Префикс отделяет субъектов токенов от API-ключей с тем же именем
+	principal := types.Principal{Subject: "jwt:" + claims.Subject, Scopes: v.scopes(custom)}
+	if v.cfg.TenantClaim != "" {
+		if id, ok := claimValue(custom, v.cfg.TenantClaim).(string); ok && id != "" {
+			if err := tenant.Validate(id); err != nil {
+				return types.Principal{}, fmt.Errorf("%w: %v", service.ErrUnauthorized, err)
+			}
+			principal.Tenant = id
+		}
+	}
+	return principal, nil
+}
+
+// scopes переводит значения claim с правами в права сервиса
+func (v *jwtVerifier) scopes(claims map[string]interface{}) []types.Scope {
+	granted := map[string]bool{}
+	for _, value := range claimValues(claims, v.cfg.PermissionsClaim) {
+		granted[value] = true
+	}
+
+	var scopes []types.Scope
+	for scope, values := range map[types.Scope][]string{
+		types.ScopeRead:  v.cfg.DownloadValues,
+		types.ScopeWrite: v.cfg.UploadValues,
+		types.ScopeAdmin: v.cfg.AdminValues,
+	} {
+		for _, value := range values {
+			if granted[value] {
+				scopes = append(scopes, scope)
+				break
+			}
+		}
+	}
+	return scopes
+}
+
+// claimValue возвращает значение claim по пути через точку
+func claimValue(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// claimValues возвращает значения claim по пути через точку.
+// Строка делится по пробелам (как scope в OAuth), массив берется поэлементно
+func claimValues(claims map[string]interface{}, path string) []string {
+	switch value := claimValue(claims, path).(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// lookup возвращает ключи с указанным kid, при необходимости обновляя JWKS по URL.
+// Одновременно выполняется не больше одной загрузки, и начинается она не чаще jwksRetryInterval,
+// даже если ключей еще нет. Загрузка идет вне блокировки и не зависит от контекста запроса;
+// запрос ждет ее, только если подходящего ключа пока нет
+func (v *jwtVerifier) lookup(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	v.mu.Lock()
+	missing := len(v.match(kid)) == 0
+	// Незнакомый kid означает смену ключей у поставщика
+	stale := v.cfg.JWKSURL != "" && (missing || time.Since(v.fetchedAt) > v.cfg.JWKSRefresh)
+	if stale && v.fetching == nil && time.Since(v.attemptedAt) > jwksRetryInterval {
+		v.attemptedAt = time.Now()
+		v.fetching = make(chan struct{})
+		go v.refresh(v.fetching)
+	}
+	fetching := v.fetching
+	v.mu.Unlock()
+
+	if fetching != nil && missing {
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.keys.Keys) == 0 && v.fetchErr != nil {
+		return nil, v.fetchErr
+	}
+	return v.match(kid), nil
+}
+
+// refresh загружает JWKS и сообщает о завершении, закрывая done.
+// При ошибке остаются прежние ключи
+func (v *jwtVerifier) refresh(done chan struct{}) {
+	keys, err := v.fetch(context.Background())
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.fetching = nil
+	close(done)
+
+	v.fetchErr = err
+	if err != nil {
+		slog.Warn("не удалось загрузить JWKS, используются прежние ключи", "error", err, "keys", len(v.keys.Keys))
+		return
+	}
+	v.keys = keys
+	v.fetchedAt = time.Now()
+}
+
+// match выбирает ключи по kid; без kid подходят все ключи набора
+func (v *jwtVerifier) match(kid string) []jose.JSONWebKey {
+	if kid == "" {
+		return v.keys.Keys
+	}
+	return v.keys.Key(kid)
+}
+
+// fetch загружает JWKS по URL
+func (v *jwtVerifier) fetch(ctx context.Context) (jose.JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("ошибка запроса JWKS: %w", err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("ошибка загрузки JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return jose.JSONWebKeySet{}, fmt.Errorf("ошибка загрузки JWKS: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("ошибка чтения JWKS: %w", err)
+	}
+
+	return parseJWKS(data)
+}
+
+// parseJWKS разбирает набор ключей и оставляет только открытые ключи
+func parseJWKS(data []byte) (jose.JSONWebKeySet, error) {
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return set, fmt.Errorf("ошибка разбора JWKS: %w", err)
+	}
+
+	keys := set.Keys[:0]
+	for _, key := range set.Keys {
+		if key.IsPublic() && key.Valid() {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return set, errors.New("в JWKS нет открытых ключей")
+	}
+	set.Keys = keys
+	return set, nil
+}
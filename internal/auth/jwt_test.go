@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	testIssuer   = "https://issuer.example"
+	testAudience = "prices-api"
+	testKeyId    = "key-1"
+)
+
+// testKey — ключ подписи токенов в тестах, общий для всех тестов пакета
+var testKey = func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// testJWKS возвращает JWKS с открытым ключом testKey
+func testJWKS(t *testing.T) []byte {
+	t.Helper()
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &testKey.PublicKey, KeyID: testKeyId, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func testJWTConfig() config.JWTConfig {
+	cfg := config.Default().Auth.JWT
+	cfg.Issuer = testIssuer
+	cfg.Audience = testAudience
+	return cfg
+}
+
+// signToken подписывает claims ключом key с алгоритмом alg и идентификатором kid
+func signToken(t *testing.T, alg jose.SignatureAlgorithm, key interface{}, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: key, KeyID: kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// validClaims возвращает claims действующего токена с изменениями из change
+func validClaims(change func(map[string]interface{})) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-1",
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"scope": "prices.download prices.upload",
+	}
+	if change != nil {
+		change(claims)
+	}
+	return claims
+}
+
+func newFileVerifier(t *testing.T, cfg config.JWTConfig) *jwtVerifier {
+	t.Helper()
+	cfg.JWKSFile = filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(cfg.JWKSFile, testJWKS(t), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := newJWTVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+func TestJWTVerifyRejects(t *testing.T) {
+	verifier := newFileVerifier(t, testJWTConfig())
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hour := time.Hour
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) {
+			c["exp"] = time.Now().Add(-hour).Unix()
+		}))},
+		{"no_exp", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) { delete(c, "exp") }))},
+		{"not_yet_valid", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) {
+			c["nbf"] = time.Now().Add(hour).Unix()
+		}))},
+		{"wrong_issuer", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) { c["iss"] = "https://evil.example" }))},
+		{"no_issuer", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) { delete(c, "iss") }))},
+		{"wrong_audience", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) { c["aud"] = "other-api" }))},
+		{"no_subject", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) { delete(c, "sub") }))},
+		{"hmac_algorithm", signToken(t, jose.HS256, []byte("0123456789abcdef0123456789abcdef"), testKeyId, validClaims(nil))},
+		{"unknown_kid", signToken(t, jose.RS256, testKey, "key-2", validClaims(nil))},
+		{"foreign_key", signToken(t, jose.RS256, otherKey, testKeyId, validClaims(nil))},
+		{"invalid_tenant", signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) { c["tenant"] = "../etc" }))},
+		{"alg_none", "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJzdWIiOiJ1c2VyLTEifQ."},
+		{"garbage", "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.verify(context.Background(), tt.token); !errors.Is(err, service.ErrUnauthorized) {
+				t.Errorf("err = %v, ожидалась ErrUnauthorized", err)
+			}
+		})
+	}
+}
+
+func TestJWTVerifyScopes(t *testing.T) {
+	cfg := testJWTConfig()
+	cfg.PermissionsClaim = "realm_access.roles"
+	verifier := newFileVerifier(t, cfg)
+
+	tests := []struct {
+		name       string
+		roles      interface{}
+		wantScopes []types.Scope
+	}{
+		{"download", []interface{}{"prices.download"}, []types.Scope{types.ScopeRead}},
+		{"upload_and_download", []interface{}{"prices.download", "prices.upload", "other"}, []types.Scope{types.ScopeRead, types.ScopeWrite}},
+		{"admin", []interface{}{"prices.admin"}, []types.Scope{types.ScopeAdmin}},
+		{"space_separated", "prices.upload prices.admin", []types.Scope{types.ScopeAdmin, types.ScopeWrite}},
+		{"unknown_values", []interface{}{"other", 42}, nil},
+		{"missing_claim", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signToken(t, jose.RS256, testKey, testKeyId, validClaims(func(c map[string]interface{}) {
+				if tt.roles != nil {
+					c["realm_access"] = map[string]interface{}{"roles": tt.roles}
+				}
+				c["tenant"] = "acme"
+			}))
+
+			principal, err := verifier.verify(context.Background(), token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.Subject != "jwt:user-1" || principal.Tenant != "acme" {
+				t.Errorf("клиент %+v, ожидался jwt:user-1 арендатора acme", principal)
+			}
+
+			got := append([]types.Scope(nil), principal.Scopes...)
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			want := append([]types.Scope(nil), tt.wantScopes...)
+			sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+			if len(got) != len(want) {
+				t.Fatalf("права %v, ожидались %v", got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("права %v, ожидались %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJWKSFetchedOnceForConcurrentRequests(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	jwks := testJWKS(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		w.Write(jwks)
+	}))
+	defer server.Close()
+
+	cfg := testJWTConfig()
+	cfg.JWKSURL = server.URL
+	verifier, err := newJWTVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := signToken(t, jose.RS256, testKey, testKeyId, validClaims(nil))
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = verifier.verify(context.Background(), token)
+		}()
+	}
+
+	// Запрос с отмененным контекстом не ждет загрузку и не прерывает ее для остальных
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := verifier.lookup(cancelled, testKeyId); !errors.Is(err, context.Canceled) {
+		t.Errorf("lookup с отмененным контекстом: %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("запрос %d: %v", i, err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("JWKS загружен %d раз, ожидалась 1 загрузка", got)
+	}
+}
+
+func TestJWKSFetchRateLimitedWithoutKeys(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := testJWTConfig()
+	cfg.JWKSURL = server.URL
+	verifier, err := newJWTVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := signToken(t, jose.RS256, testKey, testKeyId, validClaims(nil))
+	for i := 0; i < 3; i++ {
+		_, err := verifier.verify(context.Background(), token)
+		if err == nil || errors.Is(err, service.ErrUnauthorized) {
+			t.Errorf("попытка %d: err = %v, ожидалась ошибка загрузки JWKS", i, err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("JWKS запрошен %d раз, ожидалась 1 попытка за интервал", got)
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"errors"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"strings"
+)
+
+// Клиенту недоступен запрошенный арендатор
+var errTenantForbidden = errors.New("нет доступа к арендатору")
+
+// Tenants определяет арендатора запроса и сохраняет его в контексте; все запросы
+// к данным выполняются в пределах этого арендатора. Ставится после проверки клиентов
+func Tenants(cfg config.TenantConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := resolveTenant(r, cfg)
+			if errors.Is(err, errTenantForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			ctx := tenant.WithTenant(r.Context(), id)
+			ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("tenant", id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// resolveTenant выбирает арендатора. Клиент, привязанный к арендатору, работает только с ним.
+// Остальные указывают арендатора в заголовке, иначе используется арендатор по умолчанию;
+// при включенной проверке клиентов чужих арендаторов выбирают только клиенты с правом admin
+func resolveTenant(r *http.Request, cfg config.TenantConfig) (string, error) {
+	requested := strings.TrimSpace(r.Header.Get(cfg.Header))
+	principal, authenticated := service.PrincipalFromContext(r.Context())
+
+	switch {
+	case authenticated && principal.Tenant != "":
+		if requested != "" && requested != principal.Tenant {
+			return "", errTenantForbidden
+		}
+		return principal.Tenant, nil
+	case requested == "":
+		if cfg.Default == "" {
+			return "", errors.New("не указан арендатор в заголовке " + cfg.Header)
+		}
+		return cfg.Default, nil
+	case authenticated && requested != cfg.Default && !service.HasScope(principal, types.ScopeAdmin):
+		return "", errTenantForbidden
+	}
+
+	if err := tenant.Validate(requested); err != nil {
+		return "", err
+	}
+	return requested, nil
+}
@@ -0,0 +1,528 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/pkg/utils"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Конфигурация сервера.
+//
+// Значения берутся по возрастанию приоритета: значения по умолчанию, файл YAML
+// (флаг -config или переменная CONFIG_FILE), переменные окружения (тег env) и флаги
+// командной строки (тег flag). Поля с тегом secret скрываются при выводе
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Database  utils.DBConfig  `yaml:"database"`
+	Data      DataConfig      `yaml:"data"`
+	Limits    LimitsConfig    `yaml:"limits"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Timeouts  TimeoutsConfig  `yaml:"timeouts"`
+	Log       LogConfig       `yaml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Tenant    TenantConfig    `yaml:"tenant"`
+}
+
+// Настройки HTTP-сервера
+type ServerConfig struct {
+	Addr        string `yaml:"addr" env:"SERVER_ADDR" flag:"addr" usage:"адрес HTTP-сервера"`
+	RoutePrefix string `yaml:"route_prefix" env:"ROUTE_PREFIX" flag:"route-prefix" usage:"префикс маршрутов API"`
+	// Сколько ждать завершения текущих запросов при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"время ожидания текущих запросов при остановке"`
+	// Сколько после сигнала остановки отвечать 503 на /readyz, продолжая принимать запросы,
+	// чтобы балансировщик успел исключить экземпляр
+	ReadinessGrace time.Duration `yaml:"readiness_grace" env:"READINESS_GRACE" flag:"readiness-grace" usage:"задержка между снятием готовности и остановкой сервера"`
+}
+
+// Правила разбора и хранения загружаемых данных
+type DataConfig struct {
+	DateLayouts           []string `yaml:"date_layouts" env:"DATE_LAYOUTS" flag:"date-layouts" usage:"допустимые форматы даты через запятую"`
+	DuplicateKey          []string `yaml:"duplicate_key" env:"DUPLICATE_KEY" flag:"duplicate-key" usage:"поля ключа дубликата через запятую"`
+	UnknownCategoryPolicy string   `yaml:"unknown_category_policy" env:"UNKNOWN_CATEGORY_POLICY" flag:"unknown-category-policy" usage:"политика для неизвестных категорий: create, reject или quarantine"`
+	BaseCurrency          string   `yaml:"base_currency" env:"BASE_CURRENCY" flag:"base-currency" usage:"базовая валюта курсов и цен без валюты"`
+}
+
+// Ограничения на размеры ответов
+type LimitsConfig struct {
+	SearchDefault       int `yaml:"search_default" env:"SEARCH_DEFAULT_LIMIT" flag:"search-default-limit" usage:"количество результатов поиска по умолчанию"`
+	SearchMax           int `yaml:"search_max" env:"SEARCH_MAX_LIMIT" flag:"search-max-limit" usage:"максимальное количество результатов поиска"`
+	ParquetRowGroupSize int `yaml:"parquet_row_group_size" env:"PARQUET_ROW_GROUP_SIZE" flag:"parquet-row-group-size" usage:"количество строк в группе строк Parquet"`
+	MaxUploadBytes      int `yaml:"max_upload_bytes" env:"MAX_UPLOAD_BYTES" flag:"max-upload-bytes" usage:"предельный размер тела загрузки в байтах"`
+}
+
+// Ограничение частоты запросов клиентов и количества одновременных загрузок
+type RateLimitConfig struct {
+	// Клиент — API-ключ или субъект токена, без проверки клиентов — IP-адрес; 0 выключает ограничение
+	RequestsPerSecond float64 `yaml:"requests_per_second" env:"RATE_LIMIT_RPS" flag:"rate-limit-rps" usage:"запросов в секунду на клиента"`
+	Burst             int     `yaml:"burst" env:"RATE_LIMIT_BURST" flag:"rate-limit-burst" usage:"сколько запросов клиент может выполнить подряд"`
+	// 0 снимает ограничение
+	MaxConcurrentUploads int           `yaml:"max_concurrent_uploads" env:"MAX_CONCURRENT_UPLOADS" flag:"max-concurrent-uploads" usage:"сколько загрузок обрабатывается одновременно"`
+	UploadRetryAfter     time.Duration `yaml:"upload_retry_after" env:"UPLOAD_RETRY_AFTER" flag:"upload-retry-after" usage:"через сколько повторить загрузку, если все места заняты"`
+}
+
+// Ограничения времени операций с базой данных. Операция отменяется и при отключении клиента
+type TimeoutsConfig struct {
+	Upload time.Duration `yaml:"upload" env:"UPLOAD_TIMEOUT" flag:"upload-timeout" usage:"время на обработку загрузки"`
+	Export time.Duration `yaml:"export" env:"EXPORT_TIMEOUT" flag:"export-timeout" usage:"время на формирование выгрузки"`
+	Query  time.Duration `yaml:"query" env:"QUERY_TIMEOUT" flag:"query-timeout" usage:"время на остальные запросы к базе данных"`
+}
+
+// Настройки журнала
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"уровень журнала: debug, info, warn или error"`
+	Format string `yaml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"формат журнала: json или text"`
+}
+
+// Настройки трассировки OpenTelemetry
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"экспорт трасс: none, otlp, stdout или file"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" flag:"tracing-endpoint" usage:"URL приемника OTLP/HTTP, например http://localhost:4318"`
+	File        string  `yaml:"file" env:"TRACING_FILE" flag:"tracing-file" usage:"файл для экспорта file"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" flag:"tracing-service-name" usage:"имя сервиса в трассах"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" usage:"доля записываемых трасс от 0 до 1"`
+}
+
+// Настройки проверки клиентов
+type AuthConfig struct {
+	APIKeys bool      `yaml:"api_keys" env:"AUTH_API_KEYS" flag:"auth-api-keys" usage:"принимать API-ключи в заголовке X-API-Key"`
+	JWT     JWTConfig `yaml:"jwt"`
+}
+
+// Проверка JWT от поставщика удостоверений. Включается, если задан JWKS файлом или URL
+type JWTConfig struct {
+	JWKSFile    string        `yaml:"jwks_file" env:"AUTH_JWKS_FILE" flag:"auth-jwks-file" usage:"файл с ключами JWKS"`
+	JWKSURL     string        `yaml:"jwks_url" env:"AUTH_JWKS_URL" flag:"auth-jwks-url" usage:"URL ключей JWKS поставщика удостоверений"`
+	JWKSRefresh time.Duration `yaml:"jwks_refresh" env:"AUTH_JWKS_REFRESH" flag:"auth-jwks-refresh" usage:"период обновления ключей JWKS по URL"`
+	Issuer      string        `yaml:"issuer" env:"AUTH_JWT_ISSUER" flag:"auth-jwt-issuer" usage:"ожидаемый издатель (iss)"`
+	Audience    string        `yaml:"audience" env:"AUTH_JWT_AUDIENCE" flag:"auth-jwt-audience" usage:"ожидаемая аудитория (aud)"`
+	// Claim с правами: строка через пробел или массив, вложенные claims через точку (realm_access.roles)
+	PermissionsClaim string   `yaml:"permissions_claim" env:"AUTH_JWT_PERMISSIONS_CLAIM" flag:"auth-jwt-permissions-claim" usage:"claim с правами токена"`
+	DownloadValues   []string `yaml:"download_values" env:"AUTH_JWT_DOWNLOAD_VALUES" flag:"auth-jwt-download-values" usage:"значения claim, дающие право чтения"`
+	UploadValues     []string `yaml:"upload_values" env:"AUTH_JWT_UPLOAD_VALUES" flag:"auth-jwt-upload-values" usage:"значения claim, дающие право загрузки"`
+	AdminValues      []string `yaml:"admin_values" env:"AUTH_JWT_ADMIN_VALUES" flag:"auth-jwt-admin-values" usage:"значения claim, дающие права администратора"`
+	// Claim с арендатором клиента; пустой claim в токене не ограничивает арендатора
+	TenantClaim string `yaml:"tenant_claim" env:"AUTH_JWT_TENANT_CLAIM" flag:"auth-jwt-tenant-claim" usage:"claim с арендатором токена"`
+}
+
+// Разделение данных по арендаторам
+type TenantConfig struct {
+	Header string `yaml:"header" env:"TENANT_HEADER" flag:"tenant-header" usage:"заголовок с идентификатором арендатора"`
+	// Арендатор запросов без заголовка; пустое значение делает заголовок обязательным
+	Default string `yaml:"default" env:"TENANT_DEFAULT" flag:"tenant-default" usage:"арендатор по умолчанию"`
+}
+
+// Включена ли проверка JWT
+func (c JWTConfig) Enabled() bool {
+	return c.JWKSFile != "" || c.JWKSURL != ""
+}
+
+// Способы экспорта трасс
+var tracingExporters = map[string]bool{
+	"none":   true,
+	"otlp":   true,
+	"stdout": true,
+	"file":   true,
+}
+
+// Политики для категорий, которых нет в каталоге
+var unknownCategoryPolicies = map[string]bool{
+	"create":     true,
+	"reject":     true,
+	"quarantine": true,
+}
+
+// Поля товара, допустимые в ключе дубликата
+var duplicateKeyFields = map[string]bool{
+	"name":       true,
+	"category":   true,
+	"price":      true,
+	"created_at": true,
+}
+
+// Значение, которым заменяются секреты при выводе
+const redacted = "******"
+
+// Возвращает конфигурацию по умолчанию
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			RoutePrefix:     "/api/v0",
+			ShutdownTimeout: 30 * time.Second,
+			ReadinessGrace:  5 * time.Second,
+		},
+		Database: utils.DBConfig{
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "validator",
+			Password: "val1dat0r",
+			DBName:   "project-sem-1",
+			SSLMode:  "disable",
+		},
+		Data: DataConfig{
+			DateLayouts:           append([]string(nil), utils.DefaultDateLayouts...),
+			DuplicateKey:          []string{"name", "category", "price"},
+			UnknownCategoryPolicy: "create",
+			BaseCurrency:          "RUB",
+		},
+		Limits: LimitsConfig{
+			SearchDefault:       50,
+			SearchMax:           500,
+			ParquetRowGroupSize: 10000,
+			MaxUploadBytes:      100 << 20,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond:    0,
+			Burst:                20,
+			MaxConcurrentUploads: 4,
+			UploadRetryAfter:     5 * time.Second,
+		},
+		Timeouts: TimeoutsConfig{
+			Upload: 10 * time.Minute,
+			Export: 10 * time.Minute,
+			Query:  30 * time.Second,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "itmo-devops-fp1",
+			SampleRatio: 1,
+		},
+		Auth: AuthConfig{
+			JWT: JWTConfig{
+				JWKSRefresh:      time.Hour,
+				PermissionsClaim: "scope",
+				DownloadValues:   []string{"prices.download"},
+				UploadValues:     []string{"prices.upload"},
+				AdminValues:      []string{"prices.admin"},
+				TenantClaim:      "tenant",
+			},
+		},
+		Tenant: TenantConfig{
+			Header:  "X-Tenant-ID",
+			Default: tenant.Default,
+		},
+	}
+}
+
+// Загружает конфигурацию из файла, переменных окружения и аргументов командной строки.
+// printOnly сообщает, что передан флаг -print-config
+func Load(args []string) (config Config, printOnly bool, err error) {
+	config = Default()
+
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	path := flags.String("config", os.Getenv("CONFIG_FILE"), "путь к файлу конфигурации YAML")
+	flags.BoolVar(&printOnly, "print-config", false, "вывести итоговую конфигурацию и завершиться")
+
+	// Флаги применяются после файла и окружения, поэтому при разборе только запоминаются
+	flagValues := map[string]string{}
+	for _, field := range fields(&config) {
+		name := field.tag.Get("flag")
+		if name == "" {
+			continue
+		}
+		remember := func(value string) error {
+			flagValues[name] = value
+			return nil
+		}
+		// Логический флаг можно указать без значения: -auth-api-keys
+		if field.value.Kind() == reflect.Bool {
+			flags.BoolFunc(name, field.tag.Get("usage"), remember)
+		} else {
+			flags.Func(name, field.tag.Get("usage"), remember)
+		}
+	}
+	if err = flags.Parse(args); err != nil {
+		return config, false, err
+	}
+
+	if *path != "" {
+		if err = loadFile(*path, &config); err != nil {
+			return config, false, err
+		}
+	}
+
+	for _, field := range fields(&config) {
+		if name := field.tag.Get("env"); name != "" {
+			if value := os.Getenv(name); value != "" {
+				if err = setValue(field.value, value); err != nil {
+					return config, false, fmt.Errorf("переменная %s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	for _, field := range fields(&config) {
+		name := field.tag.Get("flag")
+		if value, ok := flagValues[name]; ok {
+			if err = setValue(field.value, value); err != nil {
+				return config, false, fmt.Errorf("флаг -%s: %w", name, err)
+			}
+		}
+	}
+
+	if err = config.Validate(); err != nil {
+		return config, false, err
+	}
+	return config, printOnly, nil
+}
+
+// Читает файл конфигурации поверх уже заданных значений
+func loadFile(path string, config *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл конфигурации: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil {
+		return fmt.Errorf("не удалось разобрать файл конфигурации %s: %w", path, err)
+	}
+	return nil
+}
+
+// Проверяет конфигурацию и приводит значения к каноническому виду
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr не задан"))
+	}
+	if !strings.HasPrefix(c.Server.RoutePrefix, "/") {
+		errs = append(errs, fmt.Errorf("server.route_prefix %q должен начинаться с /", c.Server.RoutePrefix))
+	}
+	c.Server.RoutePrefix = strings.TrimSuffix(c.Server.RoutePrefix, "/")
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout должен быть положительным"))
+	}
+	if c.Server.ReadinessGrace < 0 {
+		errs = append(errs, errors.New("server.readiness_grace не может быть отрицательным"))
+	}
+
+	if c.Database.Host == "" || c.Database.User == "" || c.Database.DBName == "" {
+		errs = append(errs, errors.New("database.host, database.user и database.dbname обязательны"))
+	}
+	if port, err := strconv.Atoi(c.Database.Port); err != nil || port <= 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("database.port %q не является номером порта", c.Database.Port))
+	}
+
+	if len(c.Data.DateLayouts) == 0 {
+		errs = append(errs, errors.New("data.date_layouts не может быть пустым"))
+	}
+	if len(c.Data.DuplicateKey) == 0 {
+		errs = append(errs, errors.New("data.duplicate_key не может быть пустым"))
+	}
+	for i, field := range c.Data.DuplicateKey {
+		c.Data.DuplicateKey[i] = strings.ToLower(field)
+		if !duplicateKeyFields[c.Data.DuplicateKey[i]] {
+			errs = append(errs, fmt.Errorf("неизвестное поле ключа дубликата %q", field))
+		}
+	}
+	if !unknownCategoryPolicies[c.Data.UnknownCategoryPolicy] {
+		errs = append(errs, fmt.Errorf("неизвестная политика категорий %q", c.Data.UnknownCategoryPolicy))
+	}
+	currency, err := utils.NormalizeCurrency(c.Data.BaseCurrency)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("data.base_currency: %w", err))
+	}
+	c.Data.BaseCurrency = currency
+
+	if c.Limits.SearchDefault <= 0 || c.Limits.SearchMax < c.Limits.SearchDefault {
+		errs = append(errs, errors.New("limits.search_default должен быть положительным и не больше limits.search_max"))
+	}
+	if c.Limits.ParquetRowGroupSize <= 0 {
+		errs = append(errs, errors.New("limits.parquet_row_group_size должен быть положительным"))
+	}
+	if c.Limits.MaxUploadBytes <= 0 {
+		errs = append(errs, errors.New("limits.max_upload_bytes должен быть положительным"))
+	}
+
+	if c.RateLimit.RequestsPerSecond < 0 {
+		errs = append(errs, errors.New("rate_limit.requests_per_second не может быть отрицательным"))
+	}
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst <= 0 {
+		errs = append(errs, errors.New("rate_limit.burst должен быть положительным"))
+	}
+	if c.RateLimit.MaxConcurrentUploads < 0 {
+		errs = append(errs, errors.New("rate_limit.max_concurrent_uploads не может быть отрицательным"))
+	}
+	if c.RateLimit.UploadRetryAfter <= 0 {
+		errs = append(errs, errors.New("rate_limit.upload_retry_after должен быть положительным"))
+	}
+	if c.Timeouts.Upload <= 0 || c.Timeouts.Export <= 0 || c.Timeouts.Query <= 0 {
+		errs = append(errs, errors.New("timeouts.upload, timeouts.export и timeouts.query должны быть положительными"))
+	}
+
+	if _, err := logging.New(io.Discard, c.Log.Level, c.Log.Format); err != nil {
+		errs = append(errs, fmt.Errorf("log: %w", err))
+	}
+
+	if !tracingExporters[c.Tracing.Exporter] {
+		errs = append(errs, fmt.Errorf("неизвестный способ экспорта трасс %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+		errs = append(errs, errors.New("tracing.file обязателен для экспорта file"))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio должен быть от 0 до 1"))
+	}
+
+	if c.Auth.JWT.JWKSFile != "" && c.Auth.JWT.JWKSURL != "" {
+		errs = append(errs, errors.New("auth.jwt: укажите только jwks_file или только jwks_url"))
+	}
+	if c.Auth.JWT.Enabled() {
+		if c.Auth.JWT.PermissionsClaim == "" {
+			errs = append(errs, errors.New("auth.jwt.permissions_claim не задан"))
+		}
+		if c.Auth.JWT.JWKSRefresh <= 0 {
+			errs = append(errs, errors.New("auth.jwt.jwks_refresh должен быть положительным"))
+		}
+	}
+
+	if c.Tenant.Header == "" {
+		errs = append(errs, errors.New("tenant.header не задан"))
+	}
+	if c.Tenant.Default != "" {
+		if err := tenant.Validate(c.Tenant.Default); err != nil {
+			errs = append(errs, fmt.Errorf("tenant.default: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("неверная конфигурация: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Возвращает копию конфигурации со скрытыми секретами
+func (c Config) Redacted() Config {
+	for _, field := range fields(&c) {
+		// Срезы копируются, чтобы не изменить исходную конфигурацию
+		if field.value.Kind() == reflect.Slice {
+			field.value.Set(reflect.AppendSlice(reflect.MakeSlice(field.value.Type(), 0, field.value.Len()), field.value))
+		}
+	}
+	for _, field := range fields(&c) {
+		if field.tag.Get("secret") == "true" && field.value.String() != "" {
+			field.value.SetString(redacted)
+		}
+	}
+	return c
+}
+
+// Выводит конфигурацию в формате YAML со скрытыми секретами
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<ошибка вывода конфигурации: %v>", err)
+	}
+	return string(out)
+}
+
+// Выводит конфигурацию в журнал группами по разделам, скрывая секреты
+func (c Config) LogValue() slog.Value {
+	return groupValue(reflect.ValueOf(c.Redacted()))
+}
+
+// Собирает группу slog из полей структуры с именами из тега yaml
+func groupValue(value reflect.Value) slog.Value {
+	attrs := make([]slog.Attr, 0, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("yaml"), ",")
+		field := value.Field(i)
+		if field.Kind() == reflect.Struct {
+			attrs = append(attrs, slog.Attr{Key: name, Value: groupValue(field)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(name, field.Interface()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Поле конфигурации вместе с его тегами
+type configField struct {
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+// Собирает все конечные поля конфигурации, обходя вложенные структуры
+func fields(config *Config) []configField {
+	var result []configField
+	var walk func(value reflect.Value)
+	walk = func(value reflect.Value) {
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Field(i)
+			if field.Kind() == reflect.Struct {
+				walk(field)
+				continue
+			}
+			result = append(result, configField{value: field, tag: value.Type().Field(i).Tag})
+		}
+	}
+	walk(reflect.ValueOf(config).Elem())
+	return result
+}
+
+// Записывает строковое значение из окружения или флага в поле конфигурации
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q не является длительностью", value)
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q не является целым числом", value)
+		}
+		field.SetInt(int64(number))
+	case reflect.Bool:
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q не является логическим значением", value)
+		}
+		field.SetBool(enabled)
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q не является числом", value)
+		}
+		field.SetFloat(number)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("неподдерживаемый тип поля %s", field.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig записывает файл конфигурации во временный каталог теста
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clearEnv сбрасывает переменные окружения всех полей, чтобы тест не зависел от окружения
+func clearEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	config := Default()
+	for _, field := range fields(&config) {
+		if name := field.tag.Get("env"); name != "" {
+			t.Setenv(name, "")
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeConfig(t, "server:\n  addr: \":1001\"\nlimits:\n  search_default: 10\n")
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		args       []string
+		wantAddr   string
+		wantSearch int
+	}{
+		{"defaults", nil, nil, ":8080", 50},
+		{"file_over_defaults", nil, []string{"-config", file}, ":1001", 10},
+		{"config_file_env", map[string]string{"CONFIG_FILE": file}, nil, ":1001", 10},
+		{"env_over_file", map[string]string{"SERVER_ADDR": ":1002"}, []string{"-config", file}, ":1002", 10},
+		{"flag_over_env", map[string]string{"SERVER_ADDR": ":1002", "SEARCH_DEFAULT_LIMIT": "20"},
+			[]string{"-config", file, "-addr", ":1003"}, ":1003", 20},
+		{"flag_before_config_flag", nil, []string{"-addr", ":1003", "-config", file}, ":1003", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			config, printOnly, err := Load(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if printOnly {
+				t.Error("printOnly без флага -print-config")
+			}
+			if config.Server.Addr != tt.wantAddr || config.Limits.SearchDefault != tt.wantSearch {
+				t.Errorf("addr = %q, search_default = %d, ожидались %q и %d",
+					config.Server.Addr, config.Limits.SearchDefault, tt.wantAddr, tt.wantSearch)
+			}
+		})
+	}
+}
+
+func TestLoadValueTypes(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("SHUTDOWN_TIMEOUT", "2s")
+	t.Setenv("READINESS_GRACE", "3s")
+	t.Setenv("DUPLICATE_KEY", "Name, price,")
+	t.Setenv("RATE_LIMIT_RPS", "2.5")
+
+	config, printOnly, err := Load([]string{"-auth-api-keys", "-max-upload-bytes", "1024", "-print-config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !printOnly {
+		t.Error("printOnly = false с флагом -print-config")
+	}
+	if config.Server.ShutdownTimeout != 2*time.Second {
+		t.Errorf("shutdown_timeout = %v", config.Server.ShutdownTimeout)
+	}
+	if config.Server.ReadinessGrace != 3*time.Second {
+		t.Errorf("readiness_grace = %v", config.Server.ReadinessGrace)
+	}
+	if want := []string{"name", "price"}; !reflect.DeepEqual(config.Data.DuplicateKey, want) {
+		t.Errorf("duplicate_key = %v, ожидалось %v", config.Data.DuplicateKey, want)
+	}
+	if config.RateLimit.RequestsPerSecond != 2.5 || !config.Auth.APIKeys || config.Limits.MaxUploadBytes != 1024 {
+		t.Errorf("rate_limit.requests_per_second = %v, auth.api_keys = %v, limits.max_upload_bytes = %d",
+			config.RateLimit.RequestsPerSecond, config.Auth.APIKeys, config.Limits.MaxUploadBytes)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{"unknown_file_field", nil, []string{"-config", writeConfig(t, "server:\n  adress: \":1\"\n")}, "adress"},
+		{"missing_file", nil, []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, "не удалось открыть"},
+		{"env_not_a_number", map[string]string{"SEARCH_DEFAULT_LIMIT": "many"}, nil, "SEARCH_DEFAULT_LIMIT"},
+		{"flag_not_a_duration", nil, []string{"-shutdown-timeout", "5"}, "shutdown-timeout"},
+		{"invalid_value", map[string]string{"SHUTDOWN_TIMEOUT": "-1s"}, nil, "server.shutdown_timeout"},
+		{"negative_readiness_grace", map[string]string{"READINESS_GRACE": "-1s"}, nil, "server.readiness_grace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if _, _, err := Load(tt.args); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, ожидалась ошибка с %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Config)
+		wantErr []string
+	}{
+		{"defaults", func(*Config) {}, nil},
+		{"route_prefix_trailing_slash", func(c *Config) { c.Server.RoutePrefix = "/api/" }, nil},
+		{"route_prefix_relative", func(c *Config) { c.Server.RoutePrefix = "api" }, []string{"server.route_prefix"}},
+		{"zero_shutdown_timeout", func(c *Config) { c.Server.ShutdownTimeout = 0 }, []string{"server.shutdown_timeout"}},
+		{"negative_readiness_grace", func(c *Config) { c.Server.ReadinessGrace = -time.Second }, []string{"server.readiness_grace"}},
+		{"zero_readiness_grace", func(c *Config) { c.Server.ReadinessGrace = 0 }, nil},
+		{"bad_port", func(c *Config) { c.Database.Port = "70000" }, []string{"database.port"}},
+		{"unknown_duplicate_key", func(c *Config) { c.Data.DuplicateKey = []string{"sku"} }, []string{"ключа дубликата"}},
+		{"bad_currency", func(c *Config) { c.Data.BaseCurrency = "rubles" }, []string{"data.base_currency"}},
+		{"zero_max_upload_bytes", func(c *Config) { c.Limits.MaxUploadBytes = 0 }, []string{"limits.max_upload_bytes"}},
+		{"rate_limit_without_burst", func(c *Config) { c.RateLimit.RequestsPerSecond, c.RateLimit.Burst = 1, 0 }, []string{"rate_limit.burst"}},
+		{"rate_limit_off_without_burst", func(c *Config) { c.RateLimit.RequestsPerSecond, c.RateLimit.Burst = 0, 0 }, nil},
+		{"jwks_file_and_url", func(c *Config) { c.Auth.JWT.JWKSFile, c.Auth.JWT.JWKSURL = "jwks.json", "https://idp" }, []string{"jwks_file"}},
+		{"bad_tenant_default", func(c *Config) { c.Tenant.Default = "../etc" }, []string{"tenant.default"}},
+		{"errors_collected", func(c *Config) {
+			c.Server.Addr = ""
+			c.Tracing.SampleRatio = 2
+		}, []string{"server.addr", "tracing.sample_ratio"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Default()
+			tt.change(&config)
+			err := config.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ожидалась ошибка с %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("в ошибке %q нет %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	config := Default()
+	if config.RateLimit.RequestsPerSecond != 0 {
+		t.Errorf("ограничение частоты включено по умолчанию: %v запросов в секунду", config.RateLimit.RequestsPerSecond)
+	}
+
+	// Пример конфигурации совпадает со значениями по умолчанию
+	clearEnv(t)
+	example, _, err := Load([]string{"-config", "../../config.example.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// В примере указан адрес приемника OTLP, хотя экспорт трасс выключен
+	config.Tracing.Endpoint = "http://localhost:4318"
+	if !reflect.DeepEqual(example, config) {
+		t.Errorf("config.example.yaml расходится со значениями по умолчанию:\n%s\nожидалось:\n%s", example, config)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	config := Default()
+	out := config.String()
+	if strings.Contains(out, config.Database.Password) || !strings.Contains(out, redacted) {
+		t.Errorf("пароль не скрыт:\n%s", out)
+	}
+	if config.Database.Password == redacted {
+		t.Error("Redacted изменил исходную конфигурацию")
+	}
+}
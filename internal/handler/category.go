@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"strconv"
+)
+
+// GET-запрос для получения каталога категорий
+func ListCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, err := service.GetCategories(r.Context())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// GET-запрос для получения одной категории
+func GetCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	category, err := service.GetCategory(r.Context(), id)
+	if err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+
+	writeCategory(w, category, http.StatusOK)
+}
+
+// POST-запрос для создания категории
+func CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	var category types.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	category, err := service.CreateCategory(r.Context(), category)
+	if err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", r.URL.Path+"/"+strconv.Itoa(category.Id))
+	writeCategory(w, category, http.StatusCreated)
+}
+
+// PUT-запрос для изменения категории
+func UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	var category types.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+		return
+	}
+
+	category, err := service.UpdateCategory(r.Context(), id, category)
+	if err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+
+	writeCategory(w, category, http.StatusOK)
+}
+
+// DELETE-запрос для удаления категории
+func DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteCategory(r.Context(), id); err != nil {
+		writeCategoryError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Отправляет категорию в JSON
+func writeCategory(w http.ResponseWriter, category types.Category, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(category)
+}
+
+// Сопоставляет ошибки каталога статусам HTTP
+func writeCategoryError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrCategoryConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repository.ErrInvalidParent), errors.Is(err, service.ErrInvalidRecord):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		serverError(w, r, err)
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/service"
+	"net/http"
+)
+
+// POST-запрос для загрузки курсов валют из CSV
+func UploadRatesHandler(w http.ResponseWriter, r *http.Request) {
+	count, err := service.ProcessRatesUpload(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"rates_count": count})
+}
+
+// GET-запрос для получения курсов валют
+func ListRatesHandler(w http.ResponseWriter, r *http.Request) {
+	rates, err := service.GetRates(r)
+	if errors.Is(err, service.ErrInvalidFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/service"
+	"net/http"
+)
+
+// GET-запрос для получения групп дубликатов
+func DuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	groups, err := service.GetDuplicates(r.Context())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// POST-запрос для удаления дубликатов
+func DedupeHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := service.Dedupe(r)
+	if errors.Is(err, service.ErrInvalidFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
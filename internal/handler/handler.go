@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/repository"
 	"itmo-devops-fp1/internal/service"
 	"itmo-devops-fp1/internal/types"
+	"mime"
 	"net/http"
 )
 
@@ -14,23 +19,68 @@ func UploadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем тип архива из параметра запроса
-	archiveType := r.URL.Query().Get("type")
-	if archiveType == "" {
-		archiveType = "zip" // По умолчанию zip
-	}
+	var response types.GetPricesResponse
+	var replayed bool
+	var err error
+
+	// JSON и NDJSON принимаются напрямую в теле запроса, остальное — как архив
+	if format, ok := bodyFormat(r); ok {
+		response, replayed, err = service.ProcessJSONUpload(r, format)
+	} else {
+		// Получаем тип архива из параметра запроса
+		archiveType := r.URL.Query().Get("type")
+		if archiveType == "" {
+			archiveType = "zip" // По умолчанию zip
+		}
 
-	response, err := service.ProcessUpload(r, types.ArchiveType(archiveType))
+		response, replayed, err = service.ProcessUpload(r, types.ArchiveType(archiveType))
+	}
 
+	if errors.Is(err, service.ErrIdempotencyConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, repository.ErrUnknownCategory) || errors.Is(err, repository.ErrMissingRate) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, service.ErrUploadTooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Is(err, repository.ErrMalformedJSON) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverError(w, r, err)
 		return
 	}
 
+	// Сообщаем клиенту, что ответ взят из ранее обработанной загрузки
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// Определяет формат тела запроса по заголовку Content-Type
+func bodyFormat(r *http.Request) (types.BodyFormat, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", false
+	}
+
+	switch format := types.BodyFormat(mediaType); format {
+	case types.JSON, types.NDJSON:
+		return format, true
+	default:
+		return "", false
+	}
+}
+
 // GET-запрос для скачивания данных
 func DownloadHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -38,20 +88,39 @@ func DownloadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Проверяем наличие параметров фильтрации
+	// С параметрами фильтрации возвращаем отфильтрованные данные, без них — все данные
+	download := service.ProcessDownload
 	if r.URL.Query().Has("start") {
-		err := service.ProcessFilteredDownload(w, r)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		return
+		download = service.ProcessFilteredDownload
 	}
 
-	// Если параметров нет, возвращаем все данные
-	err := service.ProcessDownload(w, r)
+	err := download(w, r)
+	if errors.Is(err, service.ErrUnsupportedFormat) || errors.Is(err, service.ErrInvalidFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repository.ErrMissingRate) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		serverError(w, r, err)
 		return
 	}
 }
+
+// Отвечает 500 и записывает ошибку в журнал с идентификатором запроса.
+// Превышение времени операции возвращается как 504, а отключившемуся клиенту не отвечаем
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	logger := logging.FromContext(r.Context()).With("method", r.Method, "path", r.URL.Path, "error", err)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Warn("превышено время обработки запроса")
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+	case r.Context().Err() != nil:
+		logger.Info("клиент отключился, обработка прервана")
+	default:
+		logger.Error("ошибка обработки запроса")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
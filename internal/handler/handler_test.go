@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadInvalidParams(t *testing.T) {
+	// Ошибки параметров отклоняются с 400 до обращения к базе данных
+	tests := []string{
+		"/prices?format=csv",
+		"/prices?currency=US",
+		"/prices?start=2024-13-01&end=2024-12-31&min=1&max=100",
+		"/prices?start=2024-01-01&end=2024-12-31&min=100&max=1",
+		"/prices?start=2024-01-01&end=2024-12-31&min=1&max=100&format=csv",
+		"/prices?start=2024-01-01&end=2024-12-31&min=1&max=100&currency_column=maybe",
+	}
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			DownloadHandler(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("статус %d, ожидался %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"encoding/json"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+)
+
+// GET-запрос проверки работоспособности: процесс запущен и отвечает
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, types.HealthResponse{Status: types.HealthOK})
+}
+
+// GET-запрос проверки готовности принимать запросы
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, service.CheckReadiness(r.Context()))
+}
+
+func writeHealth(w http.ResponseWriter, response types.HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if response.Status != types.HealthOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/service"
+	"net/http"
+)
+
+// GET-запрос для поиска товаров по артикулу, названию и категории
+func ListProductsHandler(w http.ResponseWriter, r *http.Request) {
+	identities, err := service.GetProductIdentities(r)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+}
+
+// GET-запрос для получения истории цен товара
+func PriceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := service.GetPriceHistory(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
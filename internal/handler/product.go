@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GET-запрос для получения одного товара
+func GetProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := service.GetProduct(r.Context(), id)
+	if err != nil {
+		writeProductError(w, r, err)
+		return
+	}
+
+	writeProduct(w, product, http.StatusOK)
+}
+
+// POST-запрос для создания одного товара из JSON-объекта
+func CreateProductHandler(w http.ResponseWriter, r *http.Request) {
+	object, ok := decodeProductObject(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := service.CreateProduct(r.Context(), object)
+	if err != nil {
+		writeProductError(w, r, err)
+		return
+	}
+
+	// Товар доступен по адресу /prices/{id}, а создается через /prices/item
+	w.Header().Set("Location", path.Join(path.Dir(r.URL.Path), strconv.Itoa(product.Id)))
+	writeProduct(w, product, http.StatusCreated)
+}
+
+// PUT-запрос для полной замены товара
+func ReplaceProductHandler(w http.ResponseWriter, r *http.Request) {
+	updateProduct(w, r, service.ReplaceProduct)
+}
+
+// PATCH-запрос для изменения отдельных полей товара
+func PatchProductHandler(w http.ResponseWriter, r *http.Request) {
+	updateProduct(w, r, service.PatchProduct)
+}
+
+// DELETE-запрос для удаления товара
+func DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteProduct(r.Context(), id, r.Header.Get("If-Match")); err != nil {
+		writeProductError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Общая часть PUT и PATCH
+func updateProduct(
+	w http.ResponseWriter,
+	r *http.Request,
+	update func(ctx context.Context, id int, object map[string]json.RawMessage, ifMatch string) (types.Product, error),
+) {
+	id, ok := pathId(w, r)
+	if !ok {
+		return
+	}
+
+	object, ok := decodeProductObject(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := update(r.Context(), id, object, r.Header.Get("If-Match"))
+	if err != nil {
+		writeProductError(w, r, err)
+		return
+	}
+
+	writeProduct(w, product, http.StatusOK)
+}
+
+// Получает Id из адреса запроса
+func pathId(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный формат Id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// Читает JSON-объект товара из тела запроса
+func decodeProductObject(w http.ResponseWriter, r *http.Request) (map[string]json.RawMessage, bool) {
+	var object map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&object); err != nil || object == nil {
+		http.Error(w, "ожидается JSON-объект товара", http.StatusBadRequest)
+		return nil, false
+	}
+	return object, true
+}
+
+// Отправляет товар в JSON вместе с его ETag
+func writeProduct(w http.ResponseWriter, product types.Product, status int) {
+	w.Header().Set("ETag", service.ProductETag(product))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(service.ToProductJSON(product))
+}
+
+// Сопоставляет ошибки операций с товаром статусам HTTP
+func writeProductError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrDuplicateId):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrPreconditionFailed):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, service.ErrPreconditionRequired):
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+	case errors.Is(err, service.ErrInvalidRecord), errors.Is(err, repository.ErrUnknownCategory):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		serverError(w, r, err)
+	}
+}
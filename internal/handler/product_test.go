@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestProductIfMatchRequired(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/prices/item", CreateProductHandler)
+	r.Get("/prices/{id}", GetProductHandler)
+	r.Put("/prices/{id}", ReplaceProductHandler)
+	r.Patch("/prices/{id}", PatchProductHandler)
+	r.Delete("/prices/{id}", DeleteProductHandler)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodPut, "/prices/1", `{"name": "item1"}`, http.StatusPreconditionRequired},
+		{http.MethodPatch, "/prices/1", `{"price": 200}`, http.StatusPreconditionRequired},
+		{http.MethodDelete, "/prices/1", "", http.StatusPreconditionRequired},
+		{http.MethodPut, "/prices/abc", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/prices/item", `[{"id": 1}]`, http.StatusBadRequest},
+		{http.MethodGet, "/prices/item", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("статус %d, ожидался %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GET-запрос для просмотра строк в карантине
+func ListQuarantineHandler(w http.ResponseWriter, r *http.Request) {
+	records, err := service.GetQuarantine(r.Context())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+// POST-запрос для исправления строки и переноса ее в таблицу цен
+func PromoteQuarantinedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный формат Id", http.StatusBadRequest)
+		return
+	}
+
+	// Тело запроса необязательно: без него строка переносится как есть
+	var fix types.QuarantineFix
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&fix); err != nil {
+			http.Error(w, "неверный формат JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = service.PromoteQuarantined(r.Context(), id, fix)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrDuplicateId):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvalidRecord), errors.Is(err, repository.ErrUnknownCategory):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case err != nil:
+		serverError(w, r, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DELETE-запрос для удаления одной строки из карантина
+func DeleteQuarantinedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "неверный формат Id", http.StatusBadRequest)
+		return
+	}
+
+	err = service.DeleteQuarantined(r.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE-запрос для очистки карантина одного файла (параметр source).
+// Очистка всего карантина требует явного all=true
+func PurgeQuarantineHandler(w http.ResponseWriter, r *http.Request) {
+	source := r.URL.Query().Get("source")
+	all := false
+	if value := r.URL.Query().Get("all"); value != "" {
+		var err error
+		if all, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "неверное значение all", http.StatusBadRequest)
+			return
+		}
+	}
+	if source == "" && !all {
+		http.Error(w, "укажите source или all=true для очистки всего карантина", http.StatusBadRequest)
+		return
+	}
+	if source != "" && all {
+		http.Error(w, "source и all=true нельзя указывать вместе", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := service.PurgeQuarantine(r.Context(), source)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"deleted": deleted})
+}
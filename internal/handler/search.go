@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/service"
+	"net/http"
+)
+
+// GET-запрос для поиска товаров по названию и категории
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := service.SearchProducts(r)
+	if errors.Is(err, service.ErrInvalidFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repository.ErrMissingRate) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Форматы вывода журнала
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Ключ логгера запроса в контексте
+type loggerKey struct{}
+
+// New создает логгер с заданным уровнем (debug, info, warn, error) и форматом (json, text)
+func New(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("неизвестный уровень журнала %q", level)
+	}
+
+	options := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("неизвестный формат журнала %q", format)
+}
+
+// WithLogger сохраняет логгер в контексте
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext возвращает логгер запроса с его идентификатором или логгер по умолчанию
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Middleware кладет в контекст логгер с идентификатором запроса из middleware.RequestID
+// и идентификатором трассы, если она записывается,
+// возвращает идентификатор в заголовке X-Request-Id и записывает итог каждого запроса
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestId := middleware.GetReqID(r.Context())
+		logger := slog.Default().With("request_id", requestId)
+		if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+			logger = logger.With("trace_id", span.TraceID().String())
+		}
+		if requestId != "" {
+			w.Header().Set(middleware.RequestIDHeader, requestId)
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(WithLogger(r.Context(), logger)))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger.Info("request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr,
+		)
+	})
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Префикс имен всех метрик сервиса
+const namespace = "prices"
+
+// Причины отказа с кодом 429
+const (
+	LimitRate         = "rate"
+	LimitUploads      = "uploads"
+	LimitAuthFailures = "auth_failures"
+)
+
+// Результаты обработки строк загрузки
+const (
+	RowsRead     = "read"
+	RowsInserted = "inserted"
+	RowsRejected = "rejected"
+)
+
+var (
+	// Количество HTTP-запросов по маршруту, методу и коду ответа
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Количество HTTP-запросов.",
+	}, []string{"method", "route", "status"})
+
+	// Время обработки HTTP-запросов по маршруту и методу
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки HTTP-запросов.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+	}, []string{"method", "route"})
+
+	// UploadRows считает строки загрузок: прочитанные, добавленные и отправленные в карантин
+	UploadRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_rows_total",
+		Help:      "Количество строк в обработанных загрузках по результату.",
+	}, []string{"format", "result"})
+
+	// UploadBytes считает байты обработанных архивов и тел загрузок
+	UploadBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Объем обработанных загрузок в байтах.",
+	}, []string{"format"})
+
+	// RateLimited считает запросы, отклоненные с кодом 429, по причине
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Количество запросов, отклоненных ограничением частоты или параллельных загрузок.",
+	}, []string{"reason"})
+
+	// ExportRows считает строки, отданные в выгрузках
+	ExportRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "export_rows_total",
+		Help:      "Количество строк в выгрузках.",
+	}, []string{"format"})
+)
+
+// RegisterDB добавляет статистику пула соединений с базой данных
+func RegisterDB(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware считает запросы и время их обработки по шаблону маршрута chi
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		// Шаблон, а не путь, чтобы id записей не порождали новые ряды метрик
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
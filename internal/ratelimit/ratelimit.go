@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/metrics"
+	"itmo-devops-fp1/internal/service"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/time/rate"
+)
+
+// Корзина клиента удаляется, если он не обращался дольше этого времени
+const idleTimeout = 10 * time.Minute
+
+// Как часто искать корзины неактивных клиентов
+const sweepInterval = time.Minute
+
+// Limiter ограничивает частоту запросов каждого клиента алгоритмом token bucket
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New создает ограничение частоты запросов по настройкам
+func New(cfg config.RateLimitConfig) *Limiter {
+	return &Limiter{
+		limit:     rate.Limit(cfg.RequestsPerSecond),
+		burst:     cfg.Burst,
+		clients:   make(map[string]*client),
+		lastSweep: time.Now(),
+	}
+}
+
+// Middleware отвечает 429 с заголовком Retry-After, если клиент исчерпал запросы.
+// Клиент — API-ключ или субъект токена, а без проверки клиентов — IP-адрес.
+// Ставится после проверки клиентов
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := clientKey(r)
+		reservation := l.limiter(key).Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			// Запрос отклонен, поэтому токен возвращается в корзину
+			reservation.Cancel()
+			logging.FromContext(r.Context()).Warn("превышена частота запросов", "client", key, "retry_after", delay)
+			tooManyRequests(w, metrics.LimitRate, delay)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Failures ограничивает по IP-адресу запросы, не прошедшие проверку клиентов, и ставится перед ней.
+// Каждый ответ 401 расходует токен корзины адреса; когда корзина пуста, запросы с адреса получают 429,
+// не доходя до поиска ключа в базе данных. Запросы с действующим ключом или токеном корзину не расходуют
+func (l *Limiter) Failures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ipKey(r)
+		limiter := l.limiter(key)
+		// Токен расходуется только после ответа 401, поэтому здесь лишь проверяется его наличие
+		if tokens := limiter.Tokens(); tokens < 1 {
+			delay := time.Duration((1 - tokens) / float64(l.limit) * float64(time.Second))
+			logging.FromContext(r.Context()).Warn("превышена частота неудачных проверок клиента", "client", key, "retry_after", delay)
+			tooManyRequests(w, metrics.LimitAuthFailures, delay)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if ww.Status() == http.StatusUnauthorized {
+			limiter.Allow()
+		}
+	})
+}
+
+// limiter возвращает корзину клиента, создавая ее при первом запросе
+func (l *Limiter) limiter(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleTimeout {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+// Uploads ограничивает количество одновременно обрабатываемых загрузок.
+// Если все места заняты, загрузка сразу получает 429 с Retry-After, а не ждет в очереди.
+// При MaxConcurrentUploads = 0 ограничения нет
+func Uploads(cfg config.RateLimitConfig) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, cfg.MaxConcurrentUploads)
+	return func(next http.Handler) http.Handler {
+		if cfg.MaxConcurrentUploads == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next.ServeHTTP(w, r)
+			default:
+				logging.FromContext(r.Context()).Warn("превышено количество одновременных загрузок", "limit", cfg.MaxConcurrentUploads)
+				tooManyRequests(w, metrics.LimitUploads, cfg.UploadRetryAfter)
+			}
+		})
+	}
+}
+
+// clientKey определяет клиента для подсчета запросов
+func clientKey(r *http.Request) string {
+	if principal, ok := service.PrincipalFromContext(r.Context()); ok {
+		return principal.Subject
+	}
+	return ipKey(r)
+}
+
+// ipKey определяет клиента по IP-адресу
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// tooManyRequests отвечает 429; Retry-After округляется вверх до целых секунд
+func tooManyRequests(w http.ResponseWriter, reason string, retryAfter time.Duration) {
+	metrics.RateLimited.WithLabelValues(reason).Inc()
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	http.Error(w, "слишком много запросов, повторите позже", http.StatusTooManyRequests)
+}
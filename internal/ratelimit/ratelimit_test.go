@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/service"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+// request возвращает запрос с адреса remoteAddr от клиента subject; пустой subject — без проверки клиентов
+func request(remoteAddr, subject string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v0/prices", nil)
+	r.RemoteAddr = remoteAddr
+	if subject != "" {
+		r = r.WithContext(service.WithPrincipal(r.Context(), types.Principal{Subject: subject}))
+	}
+	return r
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		subject    string
+		want       string
+	}{
+		{"ip", "10.0.0.1:5000", "", "ip:10.0.0.1"},
+		{"ipv6", "[::1]:5000", "", "ip:::1"},
+		{"ip_without_port", "10.0.0.1", "", "ip:10.0.0.1"},
+		{"jwt_subject", "10.0.0.1:5000", "jwt:alice", "jwt:alice"},
+		{"api_key", "10.0.0.1:5000", "apikey:alice", "apikey:alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientKey(request(tt.remoteAddr, tt.subject)); got != tt.want {
+				t.Errorf("clientKey = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		requests []*http.Request
+		want     []int
+	}{
+		{"burst_then_429", []*http.Request{
+			request("10.0.0.1:5000", ""), request("10.0.0.1:5001", ""), request("10.0.0.1:5002", ""),
+		}, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{"clients_counted_separately", []*http.Request{
+			request("10.0.0.1:5000", ""), request("10.0.0.1:5000", ""), request("10.0.0.2:5000", ""),
+		}, []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{"jwt_and_api_key_with_same_name", []*http.Request{
+			request("10.0.0.1:5000", "jwt:alice"), request("10.0.0.1:5000", "jwt:alice"),
+			request("10.0.0.1:5000", "apikey:alice"), request("10.0.0.1:5000", "jwt:alice"),
+		}, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(config.RateLimitConfig{RequestsPerSecond: 0.5, Burst: 2}).Middleware(okHandler)
+			for i, r := range tt.requests {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tt.want[i] {
+					t.Fatalf("запрос %d: код %d, ожидался %d", i, w.Code, tt.want[i])
+				}
+				// Токен восстанавливается за 2 секунды
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "2" {
+					t.Errorf("Retry-After = %q, ожидалось 2", w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
+
+func TestFailures(t *testing.T) {
+	// Вместо проверки клиентов: запрос с ключом проходит, без него получает 401
+	authenticate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	})
+	withKey := func(remoteAddr string) *http.Request {
+		r := request(remoteAddr, "")
+		r.Header.Set("X-API-Key", "pk_valid")
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		requests []*http.Request
+		want     []int
+	}{
+		{"valid_keys_not_counted", []*http.Request{
+			withKey("10.0.0.1:5000"), withKey("10.0.0.1:5000"), withKey("10.0.0.1:5000"),
+		}, []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{"failures_exhaust_ip", []*http.Request{
+			request("10.0.0.1:5000", ""), request("10.0.0.1:5001", ""), request("10.0.0.1:5002", ""), withKey("10.0.0.1:5003"),
+		}, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests}},
+		{"other_ip_unaffected", []*http.Request{
+			request("10.0.0.1:5000", ""), request("10.0.0.1:5000", ""), request("10.0.0.2:5000", ""),
+		}, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := New(config.RateLimitConfig{RequestsPerSecond: 0.5, Burst: 2}).Failures(authenticate)
+			for i, r := range tt.requests {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tt.want[i] {
+					t.Fatalf("запрос %d: код %d, ожидался %d", i, w.Code, tt.want[i])
+				}
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "2" {
+					t.Errorf("Retry-After = %q, ожидалось 2", w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
+
+func TestUploads(t *testing.T) {
+	cfg := config.RateLimitConfig{MaxConcurrentUploads: 1, UploadRetryAfter: 1500 * time.Millisecond}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := Uploads(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request("10.0.0.1:5000", ""))
+		done <- w.Code
+	}()
+	<-started
+
+	// Место занято: вторая загрузка сразу получает 429, Retry-After округлен вверх
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request("10.0.0.2:5000", ""))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("код %d, Retry-After %q, ожидались 429 и 2", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("первая загрузка: код %d", code)
+	}
+
+	// После освобождения места загрузка снова принимается
+	go func() { <-started }()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request("10.0.0.2:5000", ""))
+	if w.Code != http.StatusOK {
+		t.Errorf("загрузка после освобождения места: код %d", w.Code)
+	}
+}
+
+func TestUploadsUnlimited(t *testing.T) {
+	handler := Uploads(config.RateLimitConfig{})(okHandler)
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request("10.0.0.1:5000", ""))
+		if w.Code != http.StatusOK {
+			t.Fatalf("запрос %d: код %d", i, w.Code)
+		}
+	}
+}
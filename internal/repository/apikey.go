@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/types"
+
+	"github.com/lib/pq"
+)
+
+// Ключ с таким именем уже существует
+var ErrAPIKeyConflict = errors.New("API-ключ с таким именем уже существует")
+
+// Добавляет API-ключ по хешу
+func CreateAPIKey(ctx context.Context, key types.APIKey, keyHash string) (types.APIKey, error) {
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, key_hash, prefix, scopes, tenant)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at`,
+		key.Name, keyHash, key.Prefix, pq.Array(scopeStrings(key.Scopes)), key.Tenant).Scan(&key.Id, &key.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return types.APIKey{}, ErrAPIKeyConflict
+	}
+	if err != nil {
+		return types.APIKey{}, fmt.Errorf("ошибка создания API-ключа: %w", err)
+	}
+	return key, nil
+}
+
+// Возвращает все API-ключи, включая отозванные
+func FetchAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, name, prefix, scopes, COALESCE(tenant, ''), created_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения API-ключей: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []types.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Отзывает API-ключ по имени
+func RevokeAPIKey(ctx context.Context, name string) error {
+	result, err := db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE name = $1 AND revoked_at IS NULL`, name)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва API-ключа: %w", err)
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Ищет действующий API-ключ по хешу и отмечает время его использования
+func FindAPIKey(ctx context.Context, keyHash string) (types.APIKey, error) {
+	row := db.QueryRowContext(ctx, `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING id, name, prefix, scopes, COALESCE(tenant, ''), created_at, last_used_at, revoked_at`, keyHash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.APIKey{}, ErrNotFound
+	}
+	return key, err
+}
+
+// scanAPIKey читает API-ключ из строки результата
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (types.APIKey, error) {
+	var key types.APIKey
+	var scopes []string
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&key.Id, &key.Name, &key.Prefix, pq.Array(&scopes), &key.Tenant, &key.CreatedAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return types.APIKey{}, err
+	}
+
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, types.Scope(scope))
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
+
+func scopeStrings(scopes []types.Scope) []string {
+	result := make([]string, len(scopes))
+	for i, scope := range scopes {
+		result[i] = string(scope)
+	}
+	return result
+}
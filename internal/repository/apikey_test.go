@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/types"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyRevocation(t *testing.T) {
+	ctx := testTenant(t)
+
+	// Ключи не принадлежат арендатору, поэтому у ключа теста уникальное имя
+	name := fmt.Sprintf("test-key-%d", time.Now().UnixNano())
+	hash := fmt.Sprintf("hash-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM api_keys WHERE name = $1", name); err != nil {
+			t.Errorf("не удалось удалить API-ключ: %v", err)
+		}
+	})
+
+	scopes := []types.Scope{types.ScopeRead, types.ScopeWrite}
+	created, err := CreateAPIKey(ctx, types.APIKey{Name: name, Prefix: "pk_abcdef", Scopes: scopes, Tenant: "acme"}, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateAPIKey(ctx, types.APIKey{Name: name, Prefix: "pk_other", Scopes: scopes}, hash+"-other"); !errors.Is(err, ErrAPIKeyConflict) {
+		t.Errorf("ключ с тем же именем: err = %v, ожидалась ErrAPIKeyConflict", err)
+	}
+
+	key, err := FindAPIKey(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Id != created.Id || !reflect.DeepEqual(key.Scopes, scopes) || key.Tenant != "acme" || key.LastUsedAt == nil {
+		t.Errorf("найден ключ %+v, ожидался %+v с отметкой использования", key, created)
+	}
+
+	if err := RevokeAPIKey(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr error
+	}{
+		{"revoked_key_not_found", func() error { _, err := FindAPIKey(ctx, hash); return err }, ErrNotFound},
+		{"revoke_twice", func() error { return RevokeAPIKey(ctx, name) }, ErrNotFound},
+		{"unknown_hash", func() error { _, err := FindAPIKey(ctx, hash+"-unknown"); return err }, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	keys, err := FetchAPIKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if k.Name == name && k.RevokedAt == nil {
+			t.Error("отозванный ключ в списке без времени отзыва")
+		}
+	}
+}
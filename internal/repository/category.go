@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/types"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Категории нет в каталоге, а политика не разрешает ее создать
+var ErrUnknownCategory = errors.New("неизвестная категория")
+
+// Название или синоним категории уже заняты
+var ErrCategoryConflict = errors.New("категория или синоним с таким названием уже существует")
+
+// Родительская категория не найдена или образует цикл
+var ErrInvalidParent = errors.New("неверная родительская категория")
+
+// Коды ошибок PostgreSQL
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// NormalizeCategory приводит название категории к виду для сравнения:
+// нижний регистр, без лишних пробелов
+func NormalizeCategory(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// canonicalizeCategory заменяет категорию товара каноническим названием из каталога.
+// Неизвестная категория создается при политике create, иначе возвращается ErrUnknownCategory
+func canonicalizeCategory(ctx context.Context, tx *sql.Tx, product *types.Product) error {
+	normalized := NormalizeCategory(product.Category)
+
+	var canonical string
+	err := tx.QueryRowContext(ctx, `
+		SELECT name FROM categories WHERE lower(name) = $1
+		UNION ALL
+		SELECT c.name FROM category_aliases a JOIN categories c ON c.id = a.category_id WHERE a.alias = $1
+		LIMIT 1`, normalized).Scan(&canonical)
+	if err == nil {
+		product.Category = canonical
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("ошибка поиска категории: %w", err)
+	}
+
+	if unknownCategoryPolicy != types.UnknownCategoryCreate {
+		return fmt.Errorf("%w: %q", ErrUnknownCategory, product.Category)
+	}
+
+	// Новая категория сохраняется без лишних пробелов, регистр сохраняется
+	product.Category = strings.Join(strings.Fields(product.Category), " ")
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO categories (name) VALUES ($1)
+		ON CONFLICT DO NOTHING`, product.Category); err != nil {
+		return fmt.Errorf("ошибка создания категории: %w", err)
+	}
+	return nil
+}
+
+// Возвращает каталог категорий
+func FetchCategories(ctx context.Context) ([]types.Category, error) {
+	rows, err := db.QueryContext(ctx, categoriesQuery+" GROUP BY c.id ORDER BY c.name")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []types.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return categories, nil
+}
+
+// Возвращает категорию по идентификатору
+func GetCategory(ctx context.Context, id int) (types.Category, error) {
+	category, err := scanCategory(db.QueryRowContext(ctx, categoriesQuery+" WHERE c.id = $1 GROUP BY c.id", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Category{}, ErrNotFound
+	}
+	return category, err
+}
+
+// Создает категорию вместе с синонимами
+func CreateCategory(ctx context.Context, category types.Category) (types.Category, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Category{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO categories (name, parent_id) VALUES ($1, $2)
+		RETURNING id`, category.Name, category.ParentId).Scan(&category.Id)
+	if err != nil {
+		return types.Category{}, categoryError(err)
+	}
+
+	if category.Aliases, err = replaceAliases(ctx, tx, category.Id, category.Aliases); err != nil {
+		return types.Category{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Category{}, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return category, nil
+}
+
+// Изменяет категорию. Каталог общий для всех арендаторов, поэтому при переименовании
+// новое название переносится в цены и товары всех арендаторов
+func UpdateCategory(ctx context.Context, category types.Category) (types.Category, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Category{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRowContext(ctx, "SELECT name FROM categories WHERE id = $1 FOR UPDATE", category.Id).Scan(&oldName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Category{}, ErrNotFound
+	}
+	if err != nil {
+		return types.Category{}, fmt.Errorf("ошибка получения категории: %w", err)
+	}
+
+	if category.ParentId != nil {
+		if err := checkParent(ctx, tx, category.Id, *category.ParentId); err != nil {
+			return types.Category{}, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE categories SET name = $2, parent_id = $3 WHERE id = $1`,
+		category.Id, category.Name, category.ParentId); err != nil {
+		return types.Category{}, categoryError(err)
+	}
+
+	if oldName != category.Name {
+		if _, err := tx.ExecContext(ctx, "UPDATE prices SET category = $2 WHERE category = $1", oldName, category.Name); err != nil {
+			return types.Category{}, fmt.Errorf("ошибка переименования категории в ценах: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE products SET category = $2 WHERE category = $1", oldName, category.Name); err != nil {
+			return types.Category{}, categoryError(err)
+		}
+	}
+
+	if category.Aliases, err = replaceAliases(ctx, tx, category.Id, category.Aliases); err != nil {
+		return types.Category{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Category{}, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return category, nil
+}
+
+// Удаляет категорию; дочерние категории становятся корневыми
+func DeleteCategory(ctx context.Context, id int) error {
+	result, err := db.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления категории: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка получения количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Запрос категорий с синонимами; дополняется условием и группировкой
+const categoriesQuery = `
+	SELECT c.id, c.name, c.parent_id,
+		COALESCE(array_agg(a.alias ORDER BY a.alias) FILTER (WHERE a.alias IS NOT NULL), '{}')
+	FROM categories c
+	LEFT JOIN category_aliases a ON a.category_id = c.id`
+
+// scanCategory читает категорию из строки результата
+func scanCategory(row interface{ Scan(...interface{}) error }) (types.Category, error) {
+	var category types.Category
+	var parentId sql.NullInt64
+	err := row.Scan(&category.Id, &category.Name, &parentId, pq.Array(&category.Aliases))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Category{}, err
+	}
+	if err != nil {
+		return types.Category{}, fmt.Errorf("ошибка сканирования данных: %w", err)
+	}
+
+	if parentId.Valid {
+		id := int(parentId.Int64)
+		category.ParentId = &id
+	}
+	return category, nil
+}
+
+// replaceAliases заменяет синонимы категории и возвращает их в нормализованном виде
+func replaceAliases(ctx context.Context, tx *sql.Tx, categoryId int, aliases []string) ([]string, error) {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM category_aliases WHERE category_id = $1", categoryId); err != nil {
+		return nil, fmt.Errorf("ошибка удаления синонимов: %w", err)
+	}
+
+	normalized := []string{}
+	for _, alias := range aliases {
+		alias = NormalizeCategory(alias)
+		if alias == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO category_aliases (alias, category_id) VALUES ($1, $2)`,
+			alias, categoryId); err != nil {
+			return nil, categoryError(err)
+		}
+		normalized = append(normalized, alias)
+	}
+	return normalized, nil
+}
+
+// checkParent проверяет, что родитель существует и не является самой категорией или ее потомком
+func checkParent(ctx context.Context, tx *sql.Tx, categoryId, parentId int) error {
+	var cycle bool
+	err := tx.QueryRowContext(ctx, `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`, parentId, categoryId).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки родительской категории: %w", err)
+	}
+	if cycle {
+		return fmt.Errorf("%w: категория не может быть вложена в себя", ErrInvalidParent)
+	}
+	return nil
+}
+
+// categoryError переводит ошибки ограничений PostgreSQL в ошибки каталога
+func categoryError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return ErrCategoryConflict
+		case foreignKeyViolation:
+			return ErrInvalidParent
+		}
+	}
+	return fmt.Errorf("ошибка сохранения категории: %w", err)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"itmo-devops-fp1/internal/types"
+	"testing"
+	"time"
+)
+
+func TestUpdateCategoryRenamesForAllTenants(t *testing.T) {
+	ctx, other := testTenant(t), testTenant(t)
+
+	// Каталог общий, поэтому у категории теста уникальное название
+	name := fmt.Sprintf("test-category-%d", time.Now().UnixNano())
+	category, err := CreateCategory(ctx, types.Category{Name: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := DeleteCategory(ctx, category.Id); err != nil {
+			t.Errorf("не удалось удалить категорию: %v", err)
+		}
+	})
+
+	records := [][]string{{"1", "item1", name, "100", "2024-01-01"}}
+	if _, _, err := importRecords(ctx, types.Upload{ContentHash: "hash-1"}, records, "data.csv", 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := importRecords(other, types.Upload{ContentHash: "hash-1"}, records, "data.csv", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	category.Name = name + "-renamed"
+	if _, err := UpdateCategory(ctx, category); err != nil {
+		t.Fatal(err)
+	}
+
+	// Переименование переносится в цены всех арендаторов, а не только того, кто его выполнил
+	for i, tenantCtx := range []context.Context{ctx, other} {
+		products, err := FetchData(tenantCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(products) != 1 || products[0].Category != category.Name {
+			t.Errorf("арендатор %d: товары %+v, ожидалась категория %q", i, products, category.Name)
+		}
+	}
+}
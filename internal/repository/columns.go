@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Номера полей CSV-записи товара
+const (
+	idField = iota
+	nameField
+	categoryField
+	priceField
+	createdAtField
+	skuField
+	currencyField
+)
+
+// Колонка товара: допустимые названия в заголовке и обязательность
+type column struct {
+	names    []string
+	required bool
+}
+
+// Колонки товара в порядке полей CSV-записи
+var productColumns = []column{
+	{names: []string{"id"}, required: true},
+	{names: []string{"name"}, required: true},
+	{names: []string{"category"}, required: true},
+	{names: []string{"price"}, required: true},
+	{names: []string{"created_at", "create_date"}, required: true},
+	{names: []string{"sku"}},
+	{names: []string{"currency"}},
+}
+
+// Создает пустую CSV-запись товара со всеми полями
+func NewRecord() []string {
+	return make([]string, len(productColumns))
+}
+
+// mapColumns сопоставляет заголовок таблицы полям товара
+// и возвращает номер колонки для каждого поля CSV-записи (-1, если необязательной колонки нет)
+func mapColumns(header []string) ([]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	indexes := make([]int, len(productColumns))
+	for i, column := range productColumns {
+		indexes[i] = -1
+		for _, name := range column.names {
+			if position, ok := positions[name]; ok {
+				indexes[i] = position
+				break
+			}
+		}
+		if indexes[i] == -1 && column.required {
+			return nil, fmt.Errorf("в заголовке нет колонки %q", column.names[0])
+		}
+	}
+
+	return indexes, nil
+}
+
+// reorderRecord собирает CSV-запись из строки таблицы по номерам колонок
+func reorderRecord(row []string, indexes []int) []string {
+	record := make([]string, len(indexes))
+	for i, index := range indexes {
+		if index >= 0 && index < len(row) {
+			record[i] = row[index]
+		}
+	}
+	return record
+}
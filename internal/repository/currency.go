@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/types"
+	"itmo-devops-fp1/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Нет курса валюты на дату цены
+var ErrMissingRate = errors.New("нет курса валюты")
+
+// priceExpression возвращает выражение SQL для цены в валюте target.
+// Цена переводится в базовую валюту по курсу на дату created_at, затем в target;
+// если курса нет, выражение равно NULL
+func priceExpression(target string) string {
+	if target == "" {
+		return "price"
+	}
+	return "(price * " + rateExpression("prices.currency") + " / " + rateExpression(pq.QuoteLiteral(target)) + ")"
+}
+
+// rateExpression возвращает курс валюты, действующий на дату created_at
+func rateExpression(currency string) string {
+	return fmt.Sprintf(`
+		CASE WHEN %[1]s = %[2]s THEN 1 ELSE (
+			SELECT r.rate FROM exchange_rates r
+			WHERE r.currency = %[1]s AND r.rate_date <= prices.created_at
+			ORDER BY r.rate_date DESC
+			LIMIT 1
+		) END`, currency, pq.QuoteLiteral(baseCurrency))
+}
+
+// missingRateError сообщает, для какой валюты и даты не хватает курса
+func missingRateError(currency string, date time.Time) error {
+	return fmt.Errorf("%w: %s на %s", ErrMissingRate, currency, date.Format("2006-01-02"))
+}
+
+// Загружает курсы валют из CSV-файла с колонками date, currency, rate.
+// Курс на уже загруженную дату заменяется. Возвращает количество курсов
+func ProcessRatesCSV(ctx context.Context, filename string) (int, error) {
+	records, err := readCSVRecords(filename)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, errors.New("файл курсов пуст")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "currency", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return 0, fmt.Errorf("в заголовке нет колонки %q", name)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, record := range records[1:] {
+		rate, err := mapRecordToRate(reorderRecord(record, []int{columns["currency"], columns["date"], columns["rate"]}))
+		if err != nil {
+			return 0, fmt.Errorf("ошибка в строке %d: %w", i+2, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO exchange_rates (currency, rate_date, rate)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate`,
+			rate.Currency, rate.Date, rate.Rate); err != nil {
+			return 0, fmt.Errorf("ошибка сохранения курса: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return len(records) - 1, nil
+}
+
+// mapRecordToRate проверяет запись курса: валюта, дата, курс
+func mapRecordToRate(record []string) (types.ExchangeRate, error) {
+	currency, err := utils.NormalizeCurrency(record[0])
+	if err != nil {
+		return types.ExchangeRate{}, err
+	}
+	if currency == baseCurrency {
+		return types.ExchangeRate{}, fmt.Errorf("курс базовой валюты %s всегда равен 1", baseCurrency)
+	}
+
+	date, err := utils.ParseDate(record[1], dateLayouts)
+	if err != nil {
+		return types.ExchangeRate{}, fmt.Errorf("неверный формат даты: %w", err)
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+	if err != nil || rate <= 0 {
+		return types.ExchangeRate{}, errors.New("курс должен быть положительным числом")
+	}
+
+	return types.ExchangeRate{Currency: currency, Date: date.Format("2006-01-02"), Rate: rate}, nil
+}
+
+// Возвращает курсы валют; если currency не пуста — только для нее
+func FetchRates(ctx context.Context, currency string) ([]types.ExchangeRate, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT currency, to_char(rate_date, 'YYYY-MM-DD'), rate
+		FROM exchange_rates
+		WHERE $1 = '' OR currency = $1
+		ORDER BY currency, rate_date`, currency)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []types.ExchangeRate{}
+	for rows.Next() {
+		var rate types.ExchangeRate
+		if err := rows.Scan(&rate.Currency, &rate.Date, &rate.Rate); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Порядок записей в группе: первая запись остается при удалении дубликатов.
+// Записи без даты создания не выбираются по дате, пока в группе есть записи с датой
+var survivorOrders = map[types.SurvivorRule]string{
+	types.SurvivorLowestId:     "id",
+	types.SurvivorEarliestDate: "created_at ASC NULLS LAST, id",
+	types.SurvivorLatestDate:   "created_at DESC NULLS LAST, id",
+}
+
+// Возвращает группы дубликатов арендатора, идентификаторы в группе упорядочены по возрастанию
+func FetchDuplicateGroups(ctx context.Context) ([]types.DuplicateGroup, error) {
+	return fetchDuplicateGroups(ctx, db, types.SurvivorLowestId)
+}
+
+// Удаляет дубликаты, оставляя в каждой группе запись по правилу survivor.
+// При dryRun ничего не удаляется, возвращается только план
+func Dedupe(ctx context.Context, survivor types.SurvivorRule, dryRun bool) (types.DedupeResponse, error) {
+	response := types.DedupeResponse{DryRun: dryRun, Survivor: survivor, Groups: []types.DedupeGroup{}}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return response, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	groups, err := fetchDuplicateGroups(ctx, tx, survivor)
+	if err != nil {
+		return response, err
+	}
+
+	var removed []int64
+	for _, group := range groups {
+		response.Groups = append(response.Groups, types.DedupeGroup{
+			Key:        group.Key,
+			SurvivorId: group.Ids[0],
+			RemovedIds: group.Ids[1:],
+		})
+		removed = append(removed, group.Ids[1:]...)
+	}
+	response.RemovedCount = len(removed)
+
+	if dryRun || len(removed) == 0 {
+		return response, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM prices WHERE tenant = $1 AND id = ANY($2)", tenant.FromContext(ctx), pq.Array(removed)); err != nil {
+		return response, fmt.Errorf("ошибка удаления дубликатов: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return response, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return response, nil
+}
+
+// duplicateGroupsQuery возвращает запрос групп дубликатов арендатора $1:
+// значения ключа в виде текста и идентификаторы в порядке правила survivor
+func duplicateGroupsQuery(survivor types.SurvivorRule) string {
+	fields := duplicateKeyColumns()
+	keyColumns := make([]string, len(fields))
+	for i, field := range fields {
+		keyColumns[i] = field + "::text"
+	}
+
+	return fmt.Sprintf(`
+		SELECT ARRAY[%s], array_agg(id ORDER BY %s)
+		FROM prices
+		WHERE tenant = $1
+		GROUP BY %s
+		HAVING COUNT(*) > 1
+		ORDER BY MIN(id)`,
+		strings.Join(keyColumns, ", "), survivorOrders[survivor], strings.Join(fields, ", "))
+}
+
+// fetchDuplicateGroups ищет группы дубликатов; идентификаторы упорядочены по правилу survivor
+func fetchDuplicateGroups(ctx context.Context, q querier, survivor types.SurvivorRule) ([]types.DuplicateGroup, error) {
+	rows, err := q.QueryContext(ctx, duplicateGroupsQuery(survivor), tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска дубликатов: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []types.DuplicateGroup{}
+	for rows.Next() {
+		// NULL тоже образует группу, поэтому значения ключа могут отсутствовать
+		var values []sql.NullString
+		var group types.DuplicateGroup
+		if err := rows.Scan(pq.Array(&values), pq.Array(&group.Ids)); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+
+		group.Key = duplicateGroupKey(values)
+		groups = append(groups, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return groups, nil
+}
+
+// duplicateGroupKey сопоставляет значения ключа полям ключа дубликата; NULL становится nil
+func duplicateGroupKey(values []sql.NullString) map[string]*string {
+	fields := duplicateKeyColumns()
+	key := make(map[string]*string, len(fields))
+	for i, field := range fields {
+		if i < len(values) && values[i].Valid {
+			value := values[i].String
+			key[field] = &value
+		} else {
+			key[field] = nil
+		}
+	}
+	return key
+}
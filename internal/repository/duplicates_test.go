@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateGroupsQuery(t *testing.T) {
+	withDuplicateKey(t, []string{"name", "category", "price"})
+
+	tests := []struct {
+		survivor types.SurvivorRule
+		order    string
+	}{
+		{types.SurvivorLowestId, "array_agg(id ORDER BY id)"},
+		{types.SurvivorEarliestDate, "array_agg(id ORDER BY created_at ASC NULLS LAST, id)"},
+		{types.SurvivorLatestDate, "array_agg(id ORDER BY created_at DESC NULLS LAST, id)"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.survivor), func(t *testing.T) {
+			query := duplicateGroupsQuery(tt.survivor)
+			for _, want := range []string{tt.order, "ARRAY[name::text, category::text, price::text, currency::text]", "GROUP BY name, category, price, currency"} {
+				if !strings.Contains(query, want) {
+					t.Errorf("в запросе нет %q:\n%s", want, query)
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicateGroupKey(t *testing.T) {
+	withDuplicateKey(t, []string{"name", "category"})
+
+	key := duplicateGroupKey([]sql.NullString{{}, {String: "cat1", Valid: true}})
+	if value, ok := key["name"]; !ok || value != nil {
+		t.Errorf("name = %v, ожидался nil", value)
+	}
+	if value := key["category"]; value == nil || *value != "cat1" {
+		t.Errorf("category = %v, ожидалось cat1", value)
+	}
+}
+
+func TestDedupeSurvivorRules(t *testing.T) {
+	ctx := testTenant(t)
+	withDuplicateKey(t, []string{"name", "category", "price"})
+
+	rows := []struct {
+		id        int
+		name      interface{}
+		createdAt interface{}
+	}{
+		// Группа с датами и записью без даты
+		{1, "a", "2024-01-02"},
+		{2, "a", nil},
+		{3, "a", "2024-01-01"},
+		{4, "a", "2024-01-03"},
+		// Группа без дат
+		{5, "b", nil},
+		{6, "b", nil},
+		// Группа с отсутствующим значением ключа
+		{7, nil, "2024-01-01"},
+		{8, nil, "2024-01-02"},
+		// Запись без дубликатов
+		{9, "c", "2024-01-01"},
+	}
+	for _, row := range rows {
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO prices (tenant, id, created_at, name, category, price, currency)
+			VALUES ($1, $2, $3, $4, 'cat1', 100, 'RUB')`,
+			tenant.FromContext(ctx), row.id, row.createdAt, row.name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		survivor types.SurvivorRule
+		want     []int64
+	}{
+		{types.SurvivorLowestId, []int64{1, 5, 7}},
+		{types.SurvivorEarliestDate, []int64{3, 5, 7}},
+		{types.SurvivorLatestDate, []int64{4, 5, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.survivor), func(t *testing.T) {
+			response, err := Dedupe(ctx, tt.survivor, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var survivors []int64
+			for _, group := range response.Groups {
+				survivors = append(survivors, group.SurvivorId)
+			}
+			if !reflect.DeepEqual(survivors, tt.want) {
+				t.Errorf("остаются %v, ожидалось %v", survivors, tt.want)
+			}
+			if response.RemovedCount != 5 {
+				t.Errorf("к удалению %d записей, ожидалось 5", response.RemovedCount)
+			}
+			if key := response.Groups[2].Key; key["name"] != nil {
+				t.Errorf("ключ группы без названия %v, ожидалось name = nil", key)
+			}
+		})
+	}
+
+	if got := countRows(t, ctx, "prices"); got != len(rows) {
+		t.Fatalf("пробный запуск удалил записи: осталось %d из %d", got, len(rows))
+	}
+
+	if _, err := Dedupe(ctx, types.SurvivorLatestDate, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := countRows(t, ctx, "prices"); got != 4 {
+		t.Errorf("после удаления осталось %d записей, ожидалось 4", got)
+	}
+}
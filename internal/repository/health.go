@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Таблицы, которые создает scripts/prepare.sh
+var requiredTables = []string{
+	"prices",
+	"uploads",
+	"quarantine",
+	"products",
+	"price_observations",
+	"categories",
+	"category_aliases",
+	"exchange_rates",
+	"api_keys",
+}
+
+// Колонки prices, добавленные последними миграциями
+var requiredPriceColumns = []string{"currency", "search_vector", "tenant"}
+
+// PingDB проверяет, что база данных доступна
+func PingDB(ctx context.Context) error {
+	if db == nil {
+		return fmt.Errorf("подключение к базе данных не установлено")
+	}
+	return db.PingContext(ctx)
+}
+
+// CheckSchema проверяет, что миграции применены: все таблицы и новые колонки существуют
+func CheckSchema(ctx context.Context) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.name
+		FROM unnest($1::text[]) AS t(name)
+		WHERE to_regclass(t.name) IS NULL
+		UNION ALL
+		SELECT 'prices.' || c.name
+		FROM unnest($2::text[]) AS c(name)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'prices' AND column_name = c.name
+		)`,
+		pq.Array(requiredTables), pq.Array(requiredPriceColumns))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var missing []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		missing = append(missing, name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("не найдены %s, выполните scripts/prepare.sh", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+)
+
+// recordObservation добавляет наблюдение цены в историю товара,
+// создавая товар при первом упоминании
+func recordObservation(ctx context.Context, tx *sql.Tx, product types.Product) error {
+	productId, err := upsertProductIdentity(ctx, tx, product)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO price_observations (product_id, price_id, price, currency, observed_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		productId, product.Id, product.Price, product.Currency, product.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения истории цены: %w", err)
+	}
+	return nil
+}
+
+// upsertProductIdentity возвращает Id товара арендатора по артикулу, а без него — по названию и категории
+func upsertProductIdentity(ctx context.Context, tx *sql.Tx, product types.Product) (int, error) {
+	var productId int
+	var err error
+
+	if product.Sku != "" {
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO products (tenant, sku, name, category)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (tenant, sku) DO UPDATE SET name = EXCLUDED.name, category = EXCLUDED.category
+			RETURNING id`,
+			tenant.FromContext(ctx), product.Sku, product.Name, product.Category).Scan(&productId)
+	} else {
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO products (tenant, name, category)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (tenant, name, category) WHERE sku IS NULL DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`,
+			tenant.FromContext(ctx), product.Name, product.Category).Scan(&productId)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения товара: %w", err)
+	}
+
+	return productId, nil
+}
+
+// Возвращает товары арендатора, отфильтрованные по артикулу, названию и категории (пустые значения не фильтруют)
+func FetchProductIdentities(ctx context.Context, sku, name, category string) ([]types.ProductIdentity, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, COALESCE(sku, ''), name, category
+		FROM products
+		WHERE tenant = $1
+		AND ($2 = '' OR sku = $2)
+		AND ($3 = '' OR name = $3)
+		AND ($4 = '' OR category = $4)
+		ORDER BY id`, tenant.FromContext(ctx), sku, name, category)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []types.ProductIdentity{}
+	for rows.Next() {
+		var identity types.ProductIdentity
+		if err := rows.Scan(&identity.Id, &identity.Sku, &identity.Name, &identity.Category); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return identities, nil
+}
+
+// Возвращает историю цен товара арендатора в хронологическом порядке
+func FetchPriceHistory(ctx context.Context, productId int) (types.PriceHistory, error) {
+	history := types.PriceHistory{Observations: []types.PriceObservation{}}
+
+	err := db.QueryRowContext(ctx, `
+		SELECT id, COALESCE(sku, ''), name, category
+		FROM products
+		WHERE tenant = $1 AND id = $2`, tenant.FromContext(ctx), productId).Scan(
+		&history.Product.Id,
+		&history.Product.Sku,
+		&history.Product.Name,
+		&history.Product.Category,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return history, ErrNotFound
+	}
+	if err != nil {
+		return history, fmt.Errorf("ошибка получения товара: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT price_id, price, currency, to_char(observed_at, 'YYYY-MM-DD'), recorded_at
+		FROM price_observations
+		WHERE product_id = $1
+		ORDER BY observed_at, id`, productId)
+	if err != nil {
+		return history, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var observation types.PriceObservation
+		if err := rows.Scan(
+			&observation.PriceId,
+			&observation.Price,
+			&observation.Currency,
+			&observation.ObservedAt,
+			&observation.RecordedAt,
+		); err != nil {
+			return history, fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+		history.Observations = append(history.Observations, observation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return history, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return history, nil
+}
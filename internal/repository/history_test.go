@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"testing"
+)
+
+// countObservations возвращает количество наблюдений цен арендатора из ctx
+func countObservations(t *testing.T, ctx context.Context) int {
+	t.Helper()
+	var count int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM price_observations o
+		JOIN products p ON p.id = o.product_id
+		WHERE p.tenant = $1`, tenant.FromContext(ctx)).Scan(&count)
+	if err != nil {
+		t.Fatalf("не удалось посчитать наблюдения: %v", err)
+	}
+	return count
+}
+
+func TestObservationsOnlyForInsertedRows(t *testing.T) {
+	ctx := testTenant(t)
+
+	steps := []struct {
+		name             string
+		records          [][]string
+		wantInserted     int
+		wantObservations int
+	}{
+		{"new_ids", [][]string{
+			{"1", "item1", "cat1", "100", "2024-01-01"},
+			{"2", "item1", "cat1", "110", "2024-02-01"},
+		}, 2, 2},
+		// Существующий Id с другой ценой не сохраняется и не меняет историю
+		{"existing_id", [][]string{
+			{"1", "item1", "cat1", "120", "2024-03-01"},
+		}, 0, 2},
+		{"new_id_same_product", [][]string{
+			{"3", "item1", "cat1", "120", "2024-03-01"},
+		}, 1, 3},
+	}
+	for i, step := range steps {
+		upload := types.Upload{ContentHash: step.name}
+		response, _, err := importRecords(ctx, upload, step.records, "data.csv", 2)
+		if err != nil {
+			t.Fatalf("шаг %d (%s): %v", i, step.name, err)
+		}
+		if response.TotalItems != step.wantInserted {
+			t.Errorf("%s: добавлено %d строк, ожидалось %d", step.name, response.TotalItems, step.wantInserted)
+		}
+		if got := countObservations(t, ctx); got != step.wantObservations {
+			t.Errorf("%s: в истории %d наблюдений, ожидалось %d", step.name, got, step.wantObservations)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"itmo-devops-fp1/internal/tracing"
+	"itmo-devops-fp1/internal/types"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Тело запроса не является корректным JSON-массивом или NDJSON-потоком товаров
+var ErrMalformedJSON = errors.New("неверный формат JSON")
+
+// Обрабатывает JSON-массив или NDJSON-поток товаров и возвращает статистику
+func ProcessJSONFile(ctx context.Context, filename string, format types.BodyFormat, upload types.Upload) (types.GetPricesResponse, bool, error) {
+	_, span := tracing.Start(ctx, "readJSONRecords")
+	records, err := readJSONRecords(filename, format)
+	span.SetAttributes(attribute.Int("records", len(records)))
+	tracing.End(span, err)
+	if err != nil {
+		return types.GetPricesResponse{}, false, err
+	}
+
+	sourceFile := "request.json"
+	if format == types.NDJSON {
+		sourceFile = "request.ndjson"
+	}
+
+	// Номер строки в карантине — порядковый номер объекта, начиная с 1
+	return importRecords(ctx, upload, records, sourceFile, 1)
+}
+
+// readJSONRecords читает объекты товаров и приводит их к CSV-записям,
+// чтобы они проходили ту же проверку, что и строки из архива
+func readJSONRecords(filename string, format types.BodyFormat) ([][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+
+	if format == types.JSON {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedJSON, err)
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("%w: ожидается массив товаров", ErrMalformedJSON)
+		}
+	}
+
+	var records [][]string
+	for {
+		if format == types.JSON && !decoder.More() {
+			break
+		}
+
+		var object map[string]json.RawMessage
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: объект %d: %v", ErrMalformedJSON, len(records)+1, err)
+		}
+
+		records = append(records, jsonObjectToRecord(object))
+	}
+
+	if format == types.JSON {
+		// Массив должен быть закрыт, и после него не должно быть других данных
+		if token, err := decoder.Token(); err != nil || token != json.Delim(']') {
+			return nil, fmt.Errorf("%w: массив товаров не закрыт", ErrMalformedJSON)
+		}
+		if _, err := decoder.Token(); err != io.EOF {
+			return nil, fmt.Errorf("%w: данные после массива товаров", ErrMalformedJSON)
+		}
+	}
+
+	return records, nil
+}
+
+// jsonObjectToRecord преобразует JSON-объект в CSV-запись.
+// Значения не приводятся к типам здесь: это делает MapRecordToProduct
+func jsonObjectToRecord(object map[string]json.RawMessage) []string {
+	return ApplyJSONToRecord(NewRecord(), object)
+}
+
+// Заменяет в CSV-записи поля, присутствующие в JSON-объекте
+func ApplyJSONToRecord(record []string, object map[string]json.RawMessage) []string {
+	for i, column := range productColumns {
+		for _, name := range column.names {
+			if raw, ok := object[name]; ok {
+				record[i] = jsonValueToString(raw)
+				break
+			}
+		}
+	}
+	return record
+}
+
+// Преобразует товар обратно в CSV-запись
+func ProductToRecord(product types.Product) []string {
+	return []string{
+		strconv.Itoa(product.Id),
+		product.Name,
+		product.Category,
+		strconv.FormatFloat(product.Price, 'f', -1, 64),
+		product.CreatedAt.Format("2006-01-02"),
+		product.Sku,
+		product.Currency,
+	}
+}
+
+// jsonValueToString возвращает строку без кавычек или исходный текст числа
+func jsonValueToString(raw json.RawMessage) string {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value
+	}
+
+	text := strings.TrimSpace(string(raw))
+	if text == "null" {
+		return ""
+	}
+	return text
+}
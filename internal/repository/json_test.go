@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadJSONRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  types.BodyFormat
+		body    string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:   "array",
+			format: types.JSON,
+			body:   `[{"id": 1, "name": "item1", "category": "cat1", "price": 100.5, "created_at": "2024-01-01"}]`,
+			want:   [][]string{{"1", "item1", "cat1", "100.5", "2024-01-01", "", ""}},
+		},
+		{
+			name:   "empty_array",
+			format: types.JSON,
+			body:   " [ ] \n",
+		},
+		{
+			name:   "create_date_alias_and_null",
+			format: types.JSON,
+			body:   `[{"id": "2", "name": null, "category": "cat", "price": "10", "create_date": "2024-01-02"}]`,
+			want:   [][]string{{"2", "", "cat", "10", "2024-01-02", "", ""}},
+		},
+		{
+			name:   "large_number_kept_as_text",
+			format: types.JSON,
+			body:   `[{"id": 12345678901234567890, "price": 1e2}]`,
+			want:   [][]string{{"12345678901234567890", "", "", "1e2", "", "", ""}},
+		},
+		{name: "object_instead_of_array", format: types.JSON, body: `{"id": 1}`, wantErr: true},
+		{name: "empty_body", format: types.JSON, body: "", wantErr: true},
+		{name: "unclosed_array", format: types.JSON, body: `[{"id": 1}`, wantErr: true},
+		{name: "trailing_data", format: types.JSON, body: `[{"id": 1}] {"id": 2}`, wantErr: true},
+		{name: "second_array", format: types.JSON, body: `[] []`, wantErr: true},
+		{name: "array_of_numbers", format: types.JSON, body: `[1, 2]`, wantErr: true},
+		{name: "broken_object", format: types.JSON, body: `[{"id": 1,}]`, wantErr: true},
+		{
+			name:   "ndjson",
+			format: types.NDJSON,
+			body:   "{\"id\": 1, \"name\": \"a\"}\n\n{\"id\": 2, \"name\": \"b\"}\n",
+			want:   [][]string{{"1", "a", "", "", "", "", ""}, {"2", "b", "", "", "", "", ""}},
+		},
+		{name: "ndjson_empty", format: types.NDJSON, body: ""},
+		{name: "ndjson_array_line", format: types.NDJSON, body: "{\"id\": 1}\n[1]\n", wantErr: true},
+		{name: "ndjson_truncated", format: types.NDJSON, body: "{\"id\": 1}\n{\"id\": ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "upload.json")
+			if err := os.WriteFile(filename, []byte(tt.body), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readJSONRecords(filename, tt.format)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMalformedJSON) {
+					t.Fatalf("err = %v, ожидалась ErrMalformedJSON", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("записи %q, ожидались %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/tenant"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	dbOnce sync.Once
+	dbErr  error
+)
+
+// testTenant подключается к базе из переменных POSTGRES_* и возвращает контекст с отдельным
+// арендатором, данные которого удаляются после теста. Тесты с базой данных выполняются
+// только при TEST_POSTGRES=1 после scripts/prepare.sh
+func testTenant(t *testing.T) context.Context {
+	t.Helper()
+	if os.Getenv("TEST_POSTGRES") == "" {
+		t.Skip("тест требует PostgreSQL: задайте TEST_POSTGRES=1")
+	}
+
+	dbOnce.Do(func() {
+		var cfg config.Config
+		if cfg, _, dbErr = config.Load(nil); dbErr == nil {
+			dbErr = Init(cfg)
+		}
+	})
+	if dbErr != nil {
+		t.Fatalf("не удалось подключиться к базе данных: %v", dbErr)
+	}
+
+	id := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		// История цен удаляется каскадно вместе с товарами
+		for _, table := range []string{"prices", "uploads", "quarantine", "products"} {
+			if _, err := db.Exec("DELETE FROM "+table+" WHERE tenant = $1", id); err != nil {
+				t.Errorf("не удалось очистить %s: %v", table, err)
+			}
+		}
+	})
+	return tenant.WithTenant(context.Background(), id)
+}
+
+// countRows возвращает количество строк таблицы, принадлежащих арендатору из ctx
+func countRows(t *testing.T, ctx context.Context, table string) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE tenant = $1", tenant.FromContext(ctx)).Scan(&count); err != nil {
+		t.Fatalf("не удалось посчитать строки %s: %v", table, err)
+	}
+	return count
+}
+
+// withTestRate добавляет курс тестовой валюты XTS на 2024-01-01. Курсы общие для всех
+// арендаторов, поэтому курс удаляется после теста
+func withTestRate(t *testing.T) {
+	t.Helper()
+	if _, err := db.Exec("INSERT INTO exchange_rates (currency, rate_date, rate) VALUES ('XTS', '2024-01-01', 2)"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM exchange_rates WHERE currency = 'XTS'"); err != nil {
+			t.Errorf("не удалось удалить курс: %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+)
+
+// Возвращает товар арендатора по идентификатору
+func GetProduct(ctx context.Context, id int) (types.Product, error) {
+	return scanProduct(db.QueryRowContext(ctx, `
+		SELECT id, created_at, name, category, price, currency
+		FROM prices
+		WHERE tenant = $1 AND id = $2`, tenant.FromContext(ctx), id))
+}
+
+// Добавляет один товар и возвращает его с каноническим названием категории
+func CreateProduct(ctx context.Context, product types.Product) (types.Product, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Product{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := canonicalizeCategory(ctx, tx, &product); err != nil {
+		return types.Product{}, err
+	}
+
+	inserted, err := insertProduct(ctx, tx, product)
+	if err != nil {
+		return types.Product{}, err
+	}
+	if !inserted {
+		return types.Product{}, ErrDuplicateId
+	}
+
+	if err := recordObservation(ctx, tx, product); err != nil {
+		return types.Product{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Product{}, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return product, nil
+}
+
+// Изменяет товар: update получает текущую версию, заблокированную до конца транзакции,
+// и возвращает новую либо ошибку, отменяющую изменение
+func UpdateProduct(ctx context.Context, id int, update func(current types.Product) (types.Product, error)) (types.Product, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.Product{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := lockProduct(ctx, tx, id)
+	if err != nil {
+		return types.Product{}, err
+	}
+
+	updated, err := update(current)
+	if err != nil {
+		return types.Product{}, err
+	}
+
+	if err := canonicalizeCategory(ctx, tx, &updated); err != nil {
+		return types.Product{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE prices
+		SET created_at = $3, name = $4, category = $5, price = $6, currency = $7
+		WHERE tenant = $1 AND id = $2`,
+		tenant.FromContext(ctx), id, updated.CreatedAt, updated.Name, updated.Category, updated.Price, updated.Currency)
+	if err != nil {
+		return types.Product{}, fmt.Errorf("ошибка обновления товара: %w", err)
+	}
+
+	if err := recordObservation(ctx, tx, updated); err != nil {
+		return types.Product{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Product{}, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return updated, nil
+}
+
+// Удаляет товар; check получает текущую версию и может отменить удаление
+func DeleteProduct(ctx context.Context, id int, check func(current types.Product) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := lockProduct(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := check(current); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM prices WHERE tenant = $1 AND id = $2", tenant.FromContext(ctx), id); err != nil {
+		return fmt.Errorf("ошибка удаления товара: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return nil
+}
+
+// lockProduct читает товар и блокирует строку до конца транзакции
+func lockProduct(ctx context.Context, tx *sql.Tx, id int) (types.Product, error) {
+	return scanProduct(tx.QueryRowContext(ctx, `
+		SELECT id, created_at, name, category, price, currency
+		FROM prices
+		WHERE tenant = $1 AND id = $2
+		FOR UPDATE`, tenant.FromContext(ctx), id))
+}
+
+// scanProduct читает товар из результата запроса
+func scanProduct(row *sql.Row) (types.Product, error) {
+	var product types.Product
+	err := row.Scan(&product.Id, &product.CreatedAt, &product.Name, &product.Category, &product.Price, &product.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Product{}, ErrNotFound
+	}
+	if err != nil {
+		return types.Product{}, fmt.Errorf("ошибка чтения товара: %w", err)
+	}
+	return product, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+
+	"github.com/lib/pq"
+)
+
+// Запись не найдена
+var ErrNotFound = errors.New("запись не найдена")
+
+// Товар с таким Id уже существует
+var ErrDuplicateId = errors.New("товар с таким Id уже существует")
+
+// quarantineRecord сохраняет некорректную строку в карантин
+func quarantineRecord(ctx context.Context, tx *sql.Tx, record []string, sourceFile string, lineNumber int, reason string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO quarantine (tenant, raw_record, source_file, line_number, reason)
+		VALUES ($1, $2, $3, $4, $5)`,
+		tenant.FromContext(ctx), pq.Array(record), sourceFile, lineNumber, reason)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения строки в карантин: %w", err)
+	}
+	return nil
+}
+
+// Возвращает строки арендатора из карантина
+func FetchQuarantine(ctx context.Context) ([]types.QuarantinedRecord, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, raw_record, source_file, line_number, reason, created_at
+		FROM quarantine
+		WHERE tenant = $1
+		ORDER BY id`, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+
+	records := []types.QuarantinedRecord{}
+	for rows.Next() {
+		var record types.QuarantinedRecord
+		if err := rows.Scan(
+			&record.Id,
+			pq.Array(&record.RawRecord),
+			&record.SourceFile,
+			&record.LineNumber,
+			&record.Reason,
+			&record.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return records, nil
+}
+
+// Возвращает строку из карантина по идентификатору
+func GetQuarantined(ctx context.Context, id int) (types.QuarantinedRecord, error) {
+	var record types.QuarantinedRecord
+	err := db.QueryRowContext(ctx, `
+		SELECT id, raw_record, source_file, line_number, reason, created_at
+		FROM quarantine
+		WHERE tenant = $1 AND id = $2`, tenant.FromContext(ctx), id).Scan(
+		&record.Id,
+		pq.Array(&record.RawRecord),
+		&record.SourceFile,
+		&record.LineNumber,
+		&record.Reason,
+		&record.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.QuarantinedRecord{}, ErrNotFound
+	}
+	if err != nil {
+		return types.QuarantinedRecord{}, fmt.Errorf("ошибка получения строки из карантина: %w", err)
+	}
+	return record, nil
+}
+
+// Переносит исправленную строку из карантина в таблицу цен
+func PromoteQuarantined(ctx context.Context, id int, product types.Product) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM quarantine WHERE tenant = $1 AND id = $2", tenant.FromContext(ctx), id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления строки из карантина: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("ошибка получения количества удаленных строк: %w", err)
+	} else if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	if err := canonicalizeCategory(ctx, tx, &product); err != nil {
+		return err
+	}
+
+	inserted, err := insertProduct(ctx, tx, product)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		return ErrDuplicateId
+	}
+
+	if err := recordObservation(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+	return nil
+}
+
+// Удаляет строку из карантина
+func DeleteQuarantined(ctx context.Context, id int) error {
+	result, err := db.ExecContext(ctx, "DELETE FROM quarantine WHERE tenant = $1 AND id = $2", tenant.FromContext(ctx), id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления строки из карантина: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка получения количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Очищает карантин арендатора; если указан sourceFile, удаляются только строки из этого файла
+func PurgeQuarantine(ctx context.Context, sourceFile string) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		DELETE FROM quarantine
+		WHERE tenant = $1 AND ($2 = '' OR source_file = $2)`, tenant.FromContext(ctx), sourceFile)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки карантина: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения количества удаленных строк: %w", err)
+	}
+	return rowsAffected, nil
+}
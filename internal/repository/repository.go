@@ -3,22 +3,74 @@ package repository
 import (
 	"archive/tar"
 	"archive/zip"
+	"context"
 	"database/sql"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/metrics"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/tracing"
 	"itmo-devops-fp1/internal/types"
 	"itmo-devops-fp1/pkg/utils"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var db *sql.DB
 
-func init() {
-	db = utils.ConnectDB()
+// Общий интерфейс *sql.DB и *sql.Tx для запросов на чтение
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Допустимые форматы даты создания в загружаемых файлах
+var dateLayouts []string
+
+// Поля, по совпадению которых товары считаются дубликатами
+var duplicateKey []string
+
+// Действие для категорий, которых нет в каталоге
+var unknownCategoryPolicy types.UnknownCategoryPolicy
+
+// Валюта курсов и цен, для которых валюта не указана
+var baseCurrency string
+
+// Init подключается к базе данных и применяет настройки разбора данных
+func Init(cfg config.Config) error {
+	var err error
+	if db, err = utils.ConnectDB(cfg.Database); err != nil {
+		return err
+	}
+	slog.Info("Успешное подключение к базе данных", "host", cfg.Database.Host, "dbname", cfg.Database.DBName)
+	metrics.RegisterDB(db)
+	dateLayouts = cfg.Data.DateLayouts
+	duplicateKey = cfg.Data.DuplicateKey
+	unknownCategoryPolicy = types.UnknownCategoryPolicy(cfg.Data.UnknownCategoryPolicy)
+	baseCurrency = cfg.Data.BaseCurrency
+	return nil
+}
+
+// duplicateKeyColumns возвращает колонки ключа дубликата. Цены в разных валютах не сравниваются,
+// поэтому вместе с price в ключ всегда входит currency
+func duplicateKeyColumns() []string {
+	for _, field := range duplicateKey {
+		if field == "price" {
+			return append(append([]string(nil), duplicateKey...), "currency")
+		}
+	}
+	return duplicateKey
+}
+
+// duplicateKeyExpression возвращает ключ дубликата в виде строки SQL, например (name, category, price, currency)
+func duplicateKeyExpression() string {
+	return "(" + strings.Join(duplicateKeyColumns(), ", ") + ")"
 }
 
 // CloseDB закрывает соединение с базой данных
@@ -29,120 +81,94 @@ func CloseDB() {
 }
 
 // Извлекает данные из базы данных
-func FetchData() ([]types.Product, error) {
-	rows, err := db.Query("SELECT id, created_at, name, category, price FROM prices")
-	if err != nil {
-		return nil, errors.New("не удалось выполнить запрос к базе данных")
-	}
-	defer rows.Close()
+func FetchData(ctx context.Context) ([]types.Product, error) {
+	return FetchFilteredData(ctx, types.PriceFilter{})
+}
 
+// Получает отфильтрованные данные из БД
+func FetchFilteredData(ctx context.Context, filter types.PriceFilter) ([]types.Product, error) {
 	var products []types.Product
-	for rows.Next() {
-		var product types.Product
-		if err := rows.Scan(&product.Id, &product.CreatedAt, &product.Name, &product.Category, &product.Price); err != nil {
-			return nil, errors.New("ошибка чтения данных")
-		}
+	err := StreamData(ctx, filter, func(product types.Product) error {
 		products = append(products, product)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return products, nil
 }
 
-// Получает отфильтрованные данные из БД
-func FetchFilteredData(start, end string, min, max float64) ([]types.Product, error) {
+// Построчно читает отфильтрованные данные арендатора из базы и передает каждую строку в handle,
+// не загружая всю таблицу в память. Цены пересчитываются в filter.Currency, если она задана
+func StreamData(ctx context.Context, filter types.PriceFilter, handle func(types.Product) error) error {
+	conditions, args := filterConditions(ctx, filter)
 	query := `
-		SELECT id, created_at, name, category, price 
+		SELECT id, created_at, name, category, ` + priceExpression(filter.Currency) + `, currency
 		FROM prices 
-		WHERE created_at >= $1 
-		AND created_at <= $2 
-		AND price >= $3 
-		AND price <= $4
-	`
+	` + conditions
 
-	rows, err := db.Query(query, start, end, min, max)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+		return fmt.Errorf("ошибка выполнения запроса: %w", err)
 	}
 	defer rows.Close()
 
-	var products []types.Product
 	for rows.Next() {
 		var product types.Product
+		var price sql.NullFloat64
 		if err := rows.Scan(
 			&product.Id,
 			&product.CreatedAt,
 			&product.Name,
 			&product.Category,
-			&product.Price,
+			&price,
+			&product.Currency,
 		); err != nil {
-			return nil, fmt.Errorf("ошибка сканирования данных: %w", err)
+			return fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+
+		if !price.Valid {
+			return missingRateError(product.Currency, product.CreatedAt)
+		}
+		product.Price = price.Float64
+		if filter.Currency != "" {
+			product.Currency = filter.Currency
+		}
+
+		if err := handle(product); err != nil {
+			return err
 		}
-		products = append(products, product)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+		return fmt.Errorf("ошибка при итерации по результатам: %w", err)
 	}
 
-	return products, nil
+	return nil
 }
 
-// Возвращает статистику по загруженным данным
-func GetStatistics(products []types.Product) (types.GetPricesResponse, error) {
-	var response types.GetPricesResponse
+// Возвращает число разных валют среди цен, попадающих под фильтр
+func CountCurrencies(ctx context.Context, filter types.PriceFilter) (int, error) {
+	conditions, args := filterConditions(ctx, filter)
+	query := "SELECT COUNT(DISTINCT currency) FROM prices" + conditions
 
-	// Получаем все статистические данные одним запросом
-	var dbDupsCount, totalCategories int
-	var totalPrice float64
-	err := db.QueryRow(`
-		SELECT 
-			COUNT(*) - COUNT(DISTINCT (name, category, price)) as duplicates,
-			COUNT(DISTINCT category) as categories,
-			COALESCE(SUM(price), 0) as total_price
-		FROM prices
-	`).Scan(&dbDupsCount, &totalCategories, &totalPrice)
-	if err != nil {
-		return response, fmt.Errorf("ошибка получения статистики из БД: %w", err)
+	var count int
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета валют: %w", err)
 	}
-
-	response.TotalCount = len(products)
-	response.DuplicatesCount = dbDupsCount
-	response.TotalItems = countUniqueProducts(products)
-	response.TotalCategories = totalCategories
-	response.TotalPrice = totalPrice
-
-	return response, nil
+	return count, nil
 }
 
-// Подсчитывает количество уникальных товаров по всем полям, кроме id
-func countUniqueProducts(products []types.Product) int {
-	uniqueCount := 0
-	for i := 0; i < len(products); i++ {
-		isUnique := true
-		for j := 0; j < i; j++ {
-			if products[i].Name == products[j].Name &&
-				products[i].Category == products[j].Category &&
-				products[i].Price == products[j].Price {
-				isUnique = false
-				break
-			}
-		}
-		if isUnique {
-			uniqueCount++
-		}
-	}
-	return uniqueCount
-}
+// Обрабатывает ZIP-архив как загрузку upload
+func ProcessZip(ctx context.Context, filename string, upload types.Upload) (types.GetPricesResponse, bool, error) {
+	// Спан распаковки закрывается перед разбором CSV, повторный End ничего не делает
+	_, span := tracing.Start(ctx, "extractZip")
+	defer span.End()
 
-// Обрабатывает ZIP-архив
-func ProcessZip(filename string) (types.GetPricesResponse, error) {
 	reader, err := zip.OpenReader(filename)
 	if err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка открытия ZIP: %w", err)
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка открытия ZIP: %w", err)
 	}
 	defer reader.Close()
 
@@ -155,13 +181,13 @@ func ProcessZip(filename string) (types.GetPricesResponse, error) {
 	}
 
 	if csvFile == nil {
-		return types.GetPricesResponse{}, errors.New("CSV файл не найден в архиве")
+		return types.GetPricesResponse{}, false, errors.New("CSV файл не найден в архиве")
 	}
 
-	// Создаем итоговый файл для CSV
-	resultFile, err := os.Create("result.csv")
+	// Создаем отдельный временный файл для CSV
+	resultFile, err := os.CreateTemp("", "result-*.csv")
 	if err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка создания файла: %w", err)
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка создания файла: %w", err)
 	}
 	defer os.Remove(resultFile.Name())
 	defer resultFile.Close()
@@ -169,32 +195,39 @@ func ProcessZip(filename string) (types.GetPricesResponse, error) {
 	// Копируем содержимое из архива в файл
 	rc, err := csvFile.Open()
 	if err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка открытия CSV: %w", err)
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка открытия CSV: %w", err)
 	}
 	defer rc.Close()
 
-	if _, err := io.Copy(resultFile, rc); err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка копирования данных: %w", err)
+	size, err := io.Copy(resultFile, rc)
+	if err != nil {
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка копирования данных: %w", err)
 	}
+	span.SetAttributes(attribute.String("csv.name", csvFile.Name), attribute.Int64("csv.bytes", size))
+	span.End()
 
-	return ProcessCSVFile(resultFile.Name())
+	return ProcessCSVFile(ctx, resultFile.Name(), csvFile.Name, upload)
 }
 
-// Обрабатывает tar-архив
-func ProcessTar(filename string) (types.GetPricesResponse, error) {
+// Обрабатывает tar-архив как загрузку upload
+func ProcessTar(ctx context.Context, filename string, upload types.Upload) (types.GetPricesResponse, bool, error) {
+	// Спан распаковки закрывается перед разбором CSV, повторный End ничего не делает
+	_, span := tracing.Start(ctx, "extractTar")
+	defer span.End()
+
 	file, err := os.Open(filename)
 	if err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка открытия TAR: %w", err)
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка открытия TAR: %w", err)
 	}
 	defer file.Close()
 
 	tr := tar.NewReader(file)
-	var csvFound bool
+	var csvName string
 
-	// Создаем итоговый файл для CSV
-	resultFile, err := os.Create("result.csv")
+	// Создаем отдельный временный файл для CSV
+	resultFile, err := os.CreateTemp("", "result-*.csv")
 	if err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка создания файла: %w", err)
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка создания файла: %w", err)
 	}
 	defer os.Remove(resultFile.Name())
 	defer resultFile.Close()
@@ -206,24 +239,27 @@ func ProcessTar(filename string) (types.GetPricesResponse, error) {
 			break
 		}
 		if err != nil {
-			return types.GetPricesResponse{}, fmt.Errorf("ошибка чтения TAR: %w", err)
+			return types.GetPricesResponse{}, false, fmt.Errorf("ошибка чтения TAR: %w", err)
 		}
 
 		if strings.HasSuffix(header.Name, ".csv") {
 			if _, err := io.Copy(resultFile, tr); err != nil {
-				return types.GetPricesResponse{}, fmt.Errorf("ошибка копирования данных: %w", err)
+				return types.GetPricesResponse{}, false, fmt.Errorf("ошибка копирования данных: %w", err)
 			}
-			csvFound = true
+			csvName = header.Name
 			break
 		}
 	}
 
-	if !csvFound {
-		return types.GetPricesResponse{}, errors.New("CSV файл не найден в архиве")
+	if csvName == "" {
+		return types.GetPricesResponse{}, false, errors.New("CSV файл не найден в архиве")
 	}
 
+	span.SetAttributes(attribute.String("csv.name", csvName))
+	span.End()
+
 	// Используем общую логику обработки CSV
-	return ProcessCSVFile(resultFile.Name())
+	return ProcessCSVFile(ctx, resultFile.Name(), csvName, upload)
 }
 
 // readCSVRecords читает записи из CSV файла
@@ -235,6 +271,8 @@ func readCSVRecords(filename string) ([][]string, error) {
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	// Строки с неверным количеством полей проверяются позже и уходят в карантин
+	reader.FieldsPerRecord = -1
 	records, err := reader.ReadAll()
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
@@ -243,117 +281,238 @@ func readCSVRecords(filename string) ([][]string, error) {
 	return records, nil
 }
 
-// processRecords обрабатывает записи и вставляет их в БД
-func processRecords(tx *sql.Tx, records [][]string) ([]types.Product, int, error) {
-	var products []types.Product
-	var insertedCount int
-
-	// Пропускаем заголовки при обработке
-	for i := 1; i < len(records); i++ {
-		product, err := MapRecordToProduct(records[i])
-		if err != nil {
-			return nil, 0, fmt.Errorf("ошибка обработки записи %d: %w", i, err)
+// processRecords обрабатывает записи и вставляет их в БД.
+// Строки, не прошедшие проверку, отправляются в карантин;
+// firstLine — номер строки исходного файла, соответствующий первой записи
+func processRecords(ctx context.Context, tx *sql.Tx, records [][]string, sourceFile string, firstLine int) (products []types.Product, insertedCount, quarantinedCount int, err error) {
+	ctx, span := tracing.Start(ctx, "processRecords", attribute.Int("records", len(records)))
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("rows.inserted", insertedCount),
+			attribute.Int("rows.quarantined", quarantinedCount),
+		)
+		tracing.End(span, err)
+	}()
+
+	for i, record := range records {
+		product, err := MapRecordToProduct(record)
+		if err == nil {
+			err = canonicalizeCategory(ctx, tx, &product)
+			// При политике reject неизвестная категория прерывает загрузку
+			if errors.Is(err, ErrUnknownCategory) && unknownCategoryPolicy == types.UnknownCategoryReject {
+				return nil, 0, 0, fmt.Errorf("ошибка обработки строки %d: %w", firstLine+i, err)
+			}
+			if err != nil && !errors.Is(err, ErrUnknownCategory) {
+				return nil, 0, 0, err
+			}
 		}
-
-		result, err := tx.Exec(`
-			INSERT INTO prices (id, created_at, name, category, price) 
-			VALUES ($1, $2, $3, $4, $5)
-			ON CONFLICT (id) DO NOTHING`,
-			product.Id, product.CreatedAt, product.Name, product.Category, product.Price)
 		if err != nil {
-			return nil, 0, fmt.Errorf("ошибка вставки в БД: %w", err)
+			if err := quarantineRecord(ctx, tx, record, sourceFile, firstLine+i, err.Error()); err != nil {
+				return nil, 0, 0, err
+			}
+			quarantinedCount++
+			continue
 		}
 
-		rowsAffected, err := result.RowsAffected()
+		inserted, err := insertProduct(ctx, tx, product)
 		if err != nil {
-			return nil, 0, fmt.Errorf("ошибка получения количества вставленных строк: %w", err)
-		}
-		if rowsAffected > 0 {
-			insertedCount++
+			return nil, 0, 0, err
 		}
 		products = append(products, product)
+		if !inserted {
+			// Строка с тем же Id уже есть и не меняется, поэтому в историю не попадает
+			continue
+		}
+		insertedCount++
+
+		if err := recordObservation(ctx, tx, product); err != nil {
+			return nil, 0, 0, err
+		}
 	}
 
-	return products, insertedCount, nil
+	return products, insertedCount, quarantinedCount, nil
 }
 
-// getStatisticsFromTransaction получает статистику в рамках транзакции
-func getStatisticsFromTransaction(tx *sql.Tx) (int, int, float64, error) {
-	var dbDupsCount, totalCategories int
-	var totalPrice float64
+// insertProduct вставляет товар арендатора и сообщает, была ли добавлена строка.
+// Id уникален в пределах арендатора
+func insertProduct(ctx context.Context, tx *sql.Tx, product types.Product) (bool, error) {
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO prices (tenant, id, created_at, name, category, price, currency) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant, id) DO NOTHING`,
+		tenant.FromContext(ctx), product.Id, product.CreatedAt, product.Name, product.Category, product.Price, product.Currency)
+	if err != nil {
+		return false, fmt.Errorf("ошибка вставки в БД: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ошибка получения количества вставленных строк: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
 
-	err := tx.QueryRow(`
+// statisticsQuery возвращает запрос общей статистики по ценам арендатора $1.
+// Сумма цен считается в базовой валюте; отдельно считаются цены, для которых нет курса
+func statisticsQuery() string {
+	return `
 		SELECT 
-			COUNT(*) - COUNT(DISTINCT (name, category, price)) as duplicates,
+			COUNT(*) - COUNT(DISTINCT ` + duplicateKeyExpression() + `) as duplicates,
 			COUNT(DISTINCT category) as categories,
-			COALESCE(SUM(price), 0) as total_price
-		FROM prices
-	`).Scan(&dbDupsCount, &totalCategories, &totalPrice)
+			COALESCE(SUM(base_price), 0) as total_price,
+			COUNT(*) FILTER (WHERE base_price IS NULL) as missing_rates
+		FROM (
+			SELECT *, ` + priceExpression(baseCurrency) + ` AS base_price
+			FROM prices
+			WHERE tenant = $1
+		) prices
+	`
+}
+
+// getStatisticsFromTransaction получает статистику в рамках транзакции.
+// Если часть цен не пересчитать в базовую валюту, возвращается ErrMissingRate, как и при выгрузке
+func getStatisticsFromTransaction(ctx context.Context, tx *sql.Tx) (int, int, float64, error) {
+	var dbDupsCount, totalCategories, missingRates int
+	var totalPrice float64
+
+	ctx, span := tracing.Start(ctx, "getStatisticsFromTransaction")
+	err := tx.QueryRowContext(ctx, statisticsQuery(), tenant.FromContext(ctx)).Scan(&dbDupsCount, &totalCategories, &totalPrice, &missingRates)
+	tracing.End(span, err)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("ошибка получения статистики из БД: %w", err)
 	}
+	if missingRates > 0 {
+		return 0, 0, 0, fmt.Errorf("%w: не удалось пересчитать %d цен в %s", ErrMissingRate, missingRates, baseCurrency)
+	}
 
 	return dbDupsCount, totalCategories, totalPrice, nil
 }
 
-// Обрабатывает CSV файл и возвращает статистику
-func ProcessCSVFile(filename string) (types.GetPricesResponse, error) {
+// Обрабатывает CSV файл и возвращает статистику.
+// sourceFile — имя файла в архиве, сохраняется для строк из карантина
+func ProcessCSVFile(ctx context.Context, filename, sourceFile string, upload types.Upload) (types.GetPricesResponse, bool, error) {
+	_, span := tracing.Start(ctx, "readCSVRecords")
 	records, err := readCSVRecords(filename)
+	span.SetAttributes(attribute.Int("records", len(records)))
+	tracing.End(span, err)
 	if err != nil {
-		return types.GetPricesResponse{}, err
+		return types.GetPricesResponse{}, false, err
+	}
+
+	// Пропускаем заголовок: данные начинаются со второй строки файла
+	if len(records) == 0 {
+		return importRecords(ctx, upload, nil, sourceFile, 2)
+	}
+	header, records := records[0], records[1:]
+
+	// Если заголовок распознан, колонки сопоставляются по названиям,
+	// иначе используется порядок id, name, category, price, create_date
+	if indexes, err := mapColumns(header); err == nil {
+		for i, record := range records {
+			records[i] = reorderRecord(record, indexes)
+		}
 	}
 
+	return importRecords(ctx, upload, records, sourceFile, 2)
+}
+
+// importRecords загружает записи в одной транзакции вместе с записью о загрузке upload
+// и возвращает статистику. Если такая загрузка уже обработана, возвращается ее ответ и replayed
+func importRecords(ctx context.Context, upload types.Upload, records [][]string, sourceFile string, firstLine int) (response types.GetPricesResponse, replayed bool, err error) {
 	// Начинаем транзакцию
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка начала транзакции: %w", err)
 	}
 	defer tx.Rollback() // Откатываем транзакцию в случае ошибки
 
-	products, insertedCount, err := processRecords(tx, records)
+	uploadId, previous, found, err := reserveUpload(ctx, tx, upload)
+	if err != nil || found {
+		return previous.Response, found, err
+	}
+
+	_, insertedCount, quarantinedCount, err := processRecords(ctx, tx, records, sourceFile, firstLine)
 	if err != nil {
-		return types.GetPricesResponse{}, err
+		return types.GetPricesResponse{}, false, err
 	}
 
-	dbDupsCount, totalCategories, totalPrice, err := getStatisticsFromTransaction(tx)
+	dbDupsCount, totalCategories, totalPrice, err := getStatisticsFromTransaction(ctx, tx)
 	if err != nil {
-		return types.GetPricesResponse{}, err
+		return types.GetPricesResponse{}, false, err
 	}
 
-	// Подтверждаем транзакцию до формирования ответа
-	if err := tx.Commit(); err != nil {
-		return types.GetPricesResponse{}, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	response = types.GetPricesResponse{
+		TotalCount:       len(records),
+		DuplicatesCount:  dbDupsCount,
+		TotalItems:       insertedCount,
+		TotalCategories:  totalCategories,
+		TotalPrice:       totalPrice,
+		QuarantinedCount: quarantinedCount,
 	}
 
-	// Формируем ответ после успешного подтверждения транзакции
-	response := types.GetPricesResponse{
-		TotalCount:      len(products),
-		DuplicatesCount: dbDupsCount,
-		TotalItems:      insertedCount,
-		TotalCategories: totalCategories,
-		TotalPrice:      totalPrice,
+	// Ответ сохраняется вместе с данными: повтор получит его, только если загрузка подтверждена
+	if err := completeUpload(ctx, tx, uploadId, response); err != nil {
+		return types.GetPricesResponse{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return types.GetPricesResponse{}, false, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
 	}
 
-	return response, nil
+	return response, false, nil
 }
 
 // Преобразует CSV-строку в структуру Product
 func MapRecordToProduct(record []string) (types.Product, error) {
+	if len(record) < 5 {
+		return types.Product{}, errors.New("неверное количество полей")
+	}
+
 	id, err := strconv.Atoi(record[0])
 	if err != nil {
 		return types.Product{}, errors.New("неверный формат Id")
 	}
 
+	if strings.TrimSpace(record[1]) == "" {
+		return types.Product{}, errors.New("не указано название")
+	}
+
+	if strings.TrimSpace(record[2]) == "" {
+		return types.Product{}, errors.New("не указана категория")
+	}
+
 	price, err := strconv.ParseFloat(record[3], 64)
 	if err != nil {
 		return types.Product{}, errors.New("неверный формат цены")
 	}
+	if price <= 0 {
+		return types.Product{}, errors.New("цена должна быть положительной")
+	}
 
-	return types.Product{
+	createdAt, err := utils.ParseDate(record[4], dateLayouts)
+	if err != nil {
+		return types.Product{}, fmt.Errorf("неверный формат даты: %w", err)
+	}
+
+	product := types.Product{
 		Id:        id,
-		CreatedAt: record[4],
+		CreatedAt: createdAt,
 		Name:      record[1],
 		Category:  record[2],
 		Price:     price,
-	}, nil
+	}
+
+	// Необязательные поля присутствуют, только если в файле есть такие колонки
+	if len(record) > skuField {
+		product.Sku = strings.TrimSpace(record[skuField])
+	}
+
+	product.Currency = baseCurrency
+	if len(record) > currencyField && strings.TrimSpace(record[currencyField]) != "" {
+		if product.Currency, err = utils.NormalizeCurrency(record[currencyField]); err != nil {
+			return types.Product{}, err
+		}
+	}
+
+	return product, nil
 }
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"strings"
+	"testing"
+)
+
+// withDuplicateKey подменяет ключ дубликата на время теста
+func withDuplicateKey(tb testing.TB, key []string) {
+	tb.Helper()
+	saved := duplicateKey
+	duplicateKey = key
+	tb.Cleanup(func() { duplicateKey = saved })
+}
+
+func TestDuplicateKeyColumns(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"name,category", "name,category"},
+		{"name,category,price", "name,category,price,currency"},
+		{"price", "price,currency"},
+		{"created_at,price", "created_at,price,currency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			key := strings.Split(tt.key, ",")
+			withDuplicateKey(t, key)
+			if got := strings.Join(duplicateKeyColumns(), ","); got != tt.want {
+				t.Errorf("duplicateKeyColumns = %s, ожидалось %s", got, tt.want)
+			}
+			// Настройка не меняется
+			if got := strings.Join(duplicateKey, ","); got != tt.key {
+				t.Errorf("duplicateKey изменился: %s", got)
+			}
+		})
+	}
+}
+
+// tenantStatistics возвращает статистику арендатора из ctx так же, как ее считает загрузка
+func tenantStatistics(t *testing.T, ctx context.Context) (duplicates, categories int, totalPrice float64, err error) {
+	t.Helper()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	return getStatisticsFromTransaction(ctx, tx)
+}
+
+func TestStatisticsByDuplicateKey(t *testing.T) {
+	ctx := testTenant(t)
+
+	withTestRate(t)
+
+	records := [][]string{
+		{"1", "item1", "cat1", "100", "2024-01-01"},
+		{"2", "item1", "cat1", "100", "2024-01-02"},
+		{"3", "item1", "cat2", "100", "2024-01-01"},
+		{"4", "item1", "cat1", "100", "2024-01-01", "", "XTS"},
+	}
+	if _, _, err := importRecords(ctx, types.Upload{ContentHash: "hash-1"}, records, "data.csv", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key            string
+		wantDuplicates int
+	}{
+		{"name", 3},
+		{"name,category", 2},
+		// Цены в разных валютах не дубликаты
+		{"name,category,price", 1},
+		{"name,category,created_at", 1},
+		{"name,category,price,created_at", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			withDuplicateKey(t, strings.Split(tt.key, ","))
+			duplicates, categories, totalPrice, err := tenantStatistics(t, ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if duplicates != tt.wantDuplicates {
+				t.Errorf("дубликатов %d, ожидалось %d", duplicates, tt.wantDuplicates)
+			}
+			// 100 XTS по курсу 2 — 200 в базовой валюте
+			if categories != 2 || totalPrice != 500 {
+				t.Errorf("категорий %d, сумма %v; ожидалось 2 и 500", categories, totalPrice)
+			}
+		})
+	}
+}
+
+func TestImportRecordsMissingRate(t *testing.T) {
+	ctx := testTenant(t)
+
+	// Для валюты XXX курсов нет, поэтому сумму в базовой валюте не посчитать
+	records := [][]string{
+		{"1", "item1", "cat1", "100", "2024-01-01"},
+		{"2", "item2", "cat1", "100", "2024-01-01", "", "XXX"},
+	}
+	if _, _, err := importRecords(ctx, types.Upload{ContentHash: "hash-1"}, records, "data.csv", 2); !errors.Is(err, ErrMissingRate) {
+		t.Fatalf("err = %v, ожидалась ErrMissingRate", err)
+	}
+	// Загрузка откатывается целиком
+	if got := countRows(t, ctx, "prices"); got != 0 {
+		t.Errorf("в prices %d строк, ожидалось 0", got)
+	}
+}
+
+func TestCountCurrencies(t *testing.T) {
+	ctx := testTenant(t)
+	withTestRate(t)
+
+	records := [][]string{
+		{"1", "item1", "cat1", "100", "2024-01-02"},
+		{"2", "item2", "cat1", "100", "2024-01-01", "", "XTS"},
+	}
+	if _, _, err := importRecords(ctx, types.Upload{ContentHash: "hash-1"}, records, "data.csv", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter types.PriceFilter
+		want   int
+	}{
+		{"all", types.PriceFilter{}, 2},
+		{"one_day", types.PriceFilter{Start: "2024-01-02", End: "2024-01-02"}, 1},
+		{"empty", types.PriceFilter{Start: "2025-01-01", End: "2025-01-01"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CountCurrencies(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("валют %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+}
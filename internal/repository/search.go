@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"itmo-devops-fp1/internal/types"
+	"strconv"
+)
+
+// Текст, по которому ищутся товары; совпадает с выражением триграммного индекса
+const searchText = "(coalesce(name, '') || ' ' || coalesce(category, ''))"
+
+// Ищет товары по названию и категории: полнотекстовый поиск на русском и английском
+// и триграммное сходство для опечаток. Результаты упорядочены по релевантности
+func SearchProducts(ctx context.Context, q string, filter types.PriceFilter, limit int) ([]types.Product, []float64, error) {
+	conditions, args := filterConditions(ctx, filter)
+	args = append(args, q, limit)
+	queryParam := "$" + strconv.Itoa(len(args)-1)
+	limitParam := "$" + strconv.Itoa(len(args))
+
+	conditions += ` AND (search_vector @@ (plainto_tsquery('russian', ` + queryParam + `) || plainto_tsquery('english', ` + queryParam + `))
+			OR ` + queryParam + ` <% ` + searchText + `)`
+
+	query := `
+		SELECT id, created_at, name, category, ` + priceExpression(filter.Currency) + `, currency,
+			ts_rank(search_vector, plainto_tsquery('russian', ` + queryParam + `) || plainto_tsquery('english', ` + queryParam + `))
+			+ word_similarity(` + queryParam + `, ` + searchText + `) AS rank
+		FROM prices
+	` + conditions + `
+		ORDER BY rank DESC, id
+		LIMIT ` + limitParam
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка поиска: %w", err)
+	}
+	defer rows.Close()
+
+	var products []types.Product
+	var ranks []float64
+	for rows.Next() {
+		var product types.Product
+		var price sql.NullFloat64
+		var rank float64
+		if err := rows.Scan(
+			&product.Id,
+			&product.CreatedAt,
+			&product.Name,
+			&product.Category,
+			&price,
+			&product.Currency,
+			&rank,
+		); err != nil {
+			return nil, nil, fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+
+		if !price.Valid {
+			return nil, nil, missingRateError(product.Currency, product.CreatedAt)
+		}
+		product.Price = price.Float64
+		if filter.Currency != "" {
+			product.Currency = filter.Currency
+		}
+
+		products = append(products, product)
+		ranks = append(ranks, rank)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return products, ranks, nil
+}
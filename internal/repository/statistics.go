@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"strconv"
+	"strings"
+)
+
+// Выражения SQL для группировки статистики
+var groupExpressions = map[types.GroupBy]string{
+	types.GroupCategory: "category",
+	types.GroupDay:      "to_char(created_at, 'YYYY-MM-DD')",
+	types.GroupMonth:    "to_char(created_at, 'YYYY-MM')",
+}
+
+// Возвращает статистику по отфильтрованным товарам, при необходимости по группам
+func FetchStatistics(ctx context.Context, filter types.PriceFilter, groupBy types.GroupBy) ([]types.PriceStatistics, error) {
+	groupExpression := "''"
+	grouping := ""
+	if expression, ok := groupExpressions[groupBy]; ok {
+		groupExpression = expression
+		grouping = "GROUP BY 1 ORDER BY 1"
+	}
+
+	// Цены пересчитываются во вложенном запросе, агрегаты считаются по converted
+	conditions, args := filterConditions(ctx, filter)
+	query := `
+		SELECT
+			` + groupExpression + ` AS grp,
+			COUNT(*),
+			COUNT(*) - COUNT(DISTINCT ` + duplicateKeyExpression() + `),
+			COUNT(DISTINCT ` + duplicateKeyExpression() + `),
+			COALESCE(SUM(converted), 0),
+			COALESCE(MIN(converted), 0),
+			COALESCE(MAX(converted), 0),
+			COALESCE(AVG(converted), 0),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY converted), 0),
+			COUNT(*) FILTER (WHERE converted IS NULL)
+		FROM (
+			SELECT name, category, price, currency, created_at, ` + priceExpression(filter.Currency) + ` AS converted
+			FROM prices
+		` + conditions + `) AS p
+	` + grouping
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения статистики из БД: %w", err)
+	}
+	defer rows.Close()
+
+	statistics := []types.PriceStatistics{}
+	for rows.Next() {
+		var group types.PriceStatistics
+		var missingRates int
+		if err := rows.Scan(
+			&group.Group,
+			&group.TotalCount,
+			&group.DuplicatesCount,
+			&group.TotalItems,
+			&group.TotalPrice,
+			&group.MinPrice,
+			&group.MaxPrice,
+			&group.AvgPrice,
+			&group.MedianPrice,
+			&missingRates,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования данных: %w", err)
+		}
+		if missingRates > 0 {
+			return nil, fmt.Errorf("%w: не удалось пересчитать %d цен в %s", ErrMissingRate, missingRates, filter.Currency)
+		}
+		statistics = append(statistics, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+
+	return statistics, nil
+}
+
+// filterConditions строит условие WHERE для фильтра и его аргументы.
+// Первым условием всегда идет арендатор запроса
+func filterConditions(ctx context.Context, filter types.PriceFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+
+	add("tenant =", tenant.FromContext(ctx))
+	if filter.Start != "" {
+		add("created_at >=", filter.Start)
+	}
+	if filter.End != "" {
+		add("created_at <=", filter.End)
+	}
+	// Границы цены применяются к цене в валюте фильтра
+	if filter.Min > 0 {
+		add(priceExpression(filter.Currency)+" >=", filter.Min)
+	}
+	if filter.Max > 0 {
+		add(priceExpression(filter.Currency)+" <=", filter.Max)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ") + " ", args
+}
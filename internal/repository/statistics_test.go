@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"reflect"
+	"testing"
+)
+
+func TestFilterConditionsScopedToTenant(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		filter    types.PriceFilter
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{"default_tenant", context.Background(), types.PriceFilter{},
+			" WHERE tenant = $1 ", []interface{}{tenant.Default}},
+		{"request_tenant", tenant.WithTenant(context.Background(), "acme"), types.PriceFilter{},
+			" WHERE tenant = $1 ", []interface{}{"acme"}},
+		{"dates_and_prices", tenant.WithTenant(context.Background(), "acme"),
+			types.PriceFilter{Start: "2024-01-01", End: "2024-01-31", Min: 10, Max: 100},
+			" WHERE tenant = $1 AND created_at >= $2 AND created_at <= $3 AND price >= $4 AND price <= $5 ",
+			[]interface{}{"acme", "2024-01-01", "2024-01-31", 10.0, 100.0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := filterConditions(tt.ctx, tt.filter)
+			if where != tt.wantWhere {
+				t.Errorf("условие %q, ожидалось %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("аргументы %v, ожидались %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+)
+
+// Ключ идемпотентности повторно использован с другим содержимым
+var ErrIdempotencyConflict = errors.New("ключ идемпотентности уже использован для другого файла")
+
+// Общий интерфейс *sql.DB и *sql.Tx для запросов одной строки
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Ищет ранее обработанную загрузку арендатора: сначала по ключу идемпотентности, затем по хешу
+func FindPreviousUpload(ctx context.Context, upload types.Upload) (types.Upload, bool, error) {
+	return findPreviousUpload(ctx, db, upload)
+}
+
+// reserveUpload записывает загрузку в транзакции импорта до обработки строк и возвращает Id записи.
+// Блокировка по хешу содержимого и уникальный ключ идемпотентности выстраивают повторы в очередь:
+// повтор дожидается завершения первой загрузки и получает ее ответ (found равен true)
+func reserveUpload(ctx context.Context, tx *sql.Tx, upload types.Upload) (id int, previous types.Upload, found bool, err error) {
+	tenantId := tenant.FromContext(ctx)
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))", tenantId, upload.ContentHash); err != nil {
+		return 0, types.Upload{}, false, fmt.Errorf("ошибка блокировки загрузки: %w", err)
+	}
+
+	if previous, found, err := findPreviousUpload(ctx, tx, upload); err != nil || found {
+		return 0, previous, found, err
+	}
+
+	// Ответ заполняется в той же транзакции после обработки строк
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO uploads (tenant, idempotency_key, content_hash, subject, response)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), '{}')
+		ON CONFLICT (tenant, idempotency_key) DO NOTHING
+		RETURNING id`,
+		tenantId, upload.IdempotencyKey, upload.ContentHash, upload.Subject).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Загрузка с тем же ключом, но другим содержимым завершилась, пока мы ждали вставки
+		previous, found, err = findPreviousUpload(ctx, tx, upload)
+		if err == nil && !found {
+			err = errors.New("загрузка с тем же ключом идемпотентности не найдена")
+		}
+		return 0, previous, found, err
+	}
+	if err != nil {
+		return 0, types.Upload{}, false, fmt.Errorf("ошибка сохранения загрузки: %w", err)
+	}
+	return id, types.Upload{}, false, nil
+}
+
+// completeUpload сохраняет ответ обработанной загрузки
+func completeUpload(ctx context.Context, tx *sql.Tx, id int, response types.GetPricesResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации ответа: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE uploads SET response = $2 WHERE id = $1", id, data); err != nil {
+		return fmt.Errorf("ошибка сохранения загрузки: %w", err)
+	}
+	return nil
+}
+
+// findPreviousUpload ищет загрузку сначала по ключу идемпотентности, затем по хешу.
+// Ключ, уже использованный для другого содержимого, дает ErrIdempotencyConflict
+func findPreviousUpload(ctx context.Context, q rowQuerier, upload types.Upload) (types.Upload, bool, error) {
+	if upload.IdempotencyKey != "" {
+		previous, found, err := findUploadByKey(ctx, q, upload.IdempotencyKey)
+		if err != nil {
+			return types.Upload{}, false, err
+		}
+		if found {
+			if previous.ContentHash != upload.ContentHash {
+				return types.Upload{}, false, ErrIdempotencyConflict
+			}
+			return previous, true, nil
+		}
+	}
+
+	return findUploadByHash(ctx, q, upload.ContentHash)
+}
+
+func findUploadByKey(ctx context.Context, q rowQuerier, idempotencyKey string) (types.Upload, bool, error) {
+	return findUpload(ctx, q, `
+		SELECT COALESCE(idempotency_key, ''), content_hash, COALESCE(subject, ''), response
+		FROM uploads
+		WHERE tenant = $1 AND idempotency_key = $2`, idempotencyKey)
+}
+
+func findUploadByHash(ctx context.Context, q rowQuerier, contentHash string) (types.Upload, bool, error) {
+	return findUpload(ctx, q, `
+		SELECT COALESCE(idempotency_key, ''), content_hash, COALESCE(subject, ''), response
+		FROM uploads
+		WHERE tenant = $1 AND content_hash = $2
+		ORDER BY id DESC
+		LIMIT 1`, contentHash)
+}
+
+// findUpload выполняет запрос с арендатором $1 и читает одну загрузку
+func findUpload(ctx context.Context, q rowQuerier, query string, arg string) (types.Upload, bool, error) {
+	var upload types.Upload
+	var response []byte
+
+	err := q.QueryRowContext(ctx, query, tenant.FromContext(ctx), arg).Scan(&upload.IdempotencyKey, &upload.ContentHash, &upload.Subject, &response)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Upload{}, false, nil
+	}
+	if err != nil {
+		return types.Upload{}, false, fmt.Errorf("ошибка поиска загрузки: %w", err)
+	}
+
+	if err := json.Unmarshal(response, &upload.Response); err != nil {
+		return types.Upload{}, false, fmt.Errorf("ошибка чтения сохраненного ответа: %w", err)
+	}
+
+	return upload, true, nil
+}
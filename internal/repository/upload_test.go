@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"sync"
+	"testing"
+)
+
+var uploadRecords = [][]string{
+	{"1", "item1", "cat1", "100", "2024-01-01"},
+	{"2", "item2", "cat2", "200", "2024-01-15"},
+}
+
+func TestImportRecordsReplay(t *testing.T) {
+	ctx := testTenant(t)
+
+	first, replayed, err := importRecords(ctx, types.Upload{IdempotencyKey: "key-1", ContentHash: "hash-1"}, uploadRecords, "data.csv", 2)
+	if err != nil || replayed {
+		t.Fatalf("первая загрузка: replayed = %v, err = %v", replayed, err)
+	}
+
+	tests := []struct {
+		name       string
+		upload     types.Upload
+		wantReplay bool
+		wantErr    error
+	}{
+		{"same_key", types.Upload{IdempotencyKey: "key-1", ContentHash: "hash-1"}, true, nil},
+		{"same_hash_without_key", types.Upload{ContentHash: "hash-1"}, true, nil},
+		{"same_hash_new_key", types.Upload{IdempotencyKey: "key-2", ContentHash: "hash-1"}, true, nil},
+		{"key_reused_for_other_content", types.Upload{IdempotencyKey: "key-1", ContentHash: "hash-2"}, false, ErrIdempotencyConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, replayed, err := importRecords(ctx, tt.upload, uploadRecords, "data.csv", 2)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, ожидалось %v", err, tt.wantErr)
+			}
+			if replayed != tt.wantReplay {
+				t.Errorf("replayed = %v, ожидалось %v", replayed, tt.wantReplay)
+			}
+			if tt.wantReplay && response != first {
+				t.Errorf("ответ повтора %+v, ожидался исходный %+v", response, first)
+			}
+		})
+	}
+
+	if got := countRows(t, ctx, "prices"); got != len(uploadRecords) {
+		t.Errorf("в prices %d строк, ожидалось %d", got, len(uploadRecords))
+	}
+	if got := countRows(t, ctx, "uploads"); got != 1 {
+		t.Errorf("в uploads %d записей, ожидалась 1", got)
+	}
+}
+
+func TestImportRecordsConcurrentRetries(t *testing.T) {
+	ctx := testTenant(t)
+
+	// Повторы с тем же ключом и повторы без ключа с тем же содержимым
+	uploads := []types.Upload{
+		{IdempotencyKey: "key-1", ContentHash: "hash-1"},
+		{IdempotencyKey: "key-1", ContentHash: "hash-1"},
+		{ContentHash: "hash-1"},
+		{ContentHash: "hash-1"},
+	}
+
+	var wg sync.WaitGroup
+	results := make([]bool, len(uploads))
+	errs := make([]error, len(uploads))
+	for i, upload := range uploads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i], errs[i] = importRecords(ctx, upload, uploadRecords, "data.csv", 2)
+		}()
+	}
+	wg.Wait()
+
+	processed := 0
+	for i, replayed := range results {
+		if errs[i] != nil {
+			t.Fatalf("загрузка %d: %v", i, errs[i])
+		}
+		if !replayed {
+			processed++
+		}
+	}
+	if processed != 1 {
+		t.Errorf("обработано %d загрузок, ожидалась 1", processed)
+	}
+	if got := countRows(t, ctx, "uploads"); got != 1 {
+		t.Errorf("в uploads %d записей, ожидалась 1", got)
+	}
+	if got := countRows(t, ctx, "prices"); got != len(uploadRecords) {
+		t.Errorf("в prices %d строк, ожидалось %d", got, len(uploadRecords))
+	}
+}
+
+func TestUploadsIsolatedByTenant(t *testing.T) {
+	ctx, other := testTenant(t), testTenant(t)
+
+	upload := types.Upload{IdempotencyKey: "key-1", ContentHash: "hash-1"}
+	if _, _, err := importRecords(ctx, upload, uploadRecords, "data.csv", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Тот же ключ и те же Id у другого арендатора — новая загрузка, а не повтор или конфликт
+	response, replayed, err := importRecords(other, upload, uploadRecords[:1], "data.csv", 2)
+	if err != nil || replayed {
+		t.Fatalf("загрузка другого арендатора: replayed = %v, err = %v", replayed, err)
+	}
+	if response.TotalItems != 1 {
+		t.Errorf("другому арендатору загружено %d строк, ожидалась 1", response.TotalItems)
+	}
+
+	tests := []struct {
+		name       string
+		filter     types.PriceFilter
+		wantCounts [2]int
+	}{
+		{"all", types.PriceFilter{}, [2]int{2, 1}},
+		{"by_date", types.PriceFilter{Start: "2024-01-10", End: "2024-01-31"}, [2]int{1, 0}},
+		{"by_price", types.PriceFilter{Min: 150}, [2]int{1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, tenantCtx := range []context.Context{ctx, other} {
+				products, err := FetchFilteredData(tenantCtx, tt.filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(products) != tt.wantCounts[i] {
+					t.Errorf("арендатор %d: %d строк, ожидалось %d", i, len(products), tt.wantCounts[i])
+				}
+			}
+		})
+	}
+
+	for i, tenantCtx := range []context.Context{ctx, other} {
+		if got := countRows(t, tenantCtx, "uploads"); got != 1 {
+			t.Errorf("арендатор %d: в uploads %d записей, ожидалась 1", i, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/tracing"
+	"itmo-devops-fp1/internal/types"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Обрабатывает XLSX-файл: берет указанный или первый лист,
+// сопоставляет колонки по заголовку и загружает строки как CSV-записи
+func ProcessXLSX(ctx context.Context, filename, sheet string, upload types.Upload) (types.GetPricesResponse, bool, error) {
+	_, span := tracing.Start(ctx, "readXLSXRecords")
+	records, sheet, err := readXLSXRecords(filename, sheet)
+	span.SetAttributes(attribute.Int("records", len(records)))
+	tracing.End(span, err)
+	if err != nil {
+		return types.GetPricesResponse{}, false, err
+	}
+
+	// Первая строка листа — заголовок, данные начинаются со второй
+	return importRecords(ctx, upload, records, sheet, 2)
+}
+
+// readXLSXRecords читает строки листа и возвращает их вместе с именем листа
+func readXLSXRecords(filename, sheet string) ([][]string, string, error) {
+	file, err := excelize.OpenFile(filename, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка открытия XLSX: %w", err)
+	}
+	defer file.Close()
+
+	if sheet == "" {
+		sheet = file.GetSheetName(0)
+	}
+	if index, err := file.GetSheetIndex(sheet); err != nil || index == -1 {
+		return nil, "", fmt.Errorf("лист %q не найден", sheet)
+	}
+
+	rows, err := file.GetRows(sheet)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка чтения листа %q: %w", sheet, err)
+	}
+	if len(rows) == 0 {
+		return nil, "", errors.New("лист не содержит заголовка")
+	}
+
+	indexes, err := mapColumns(rows[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	records := make([][]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := reorderRecord(row, indexes)
+		record[createdAtField] = excelDateToString(record[createdAtField])
+		records = append(records, record)
+	}
+
+	return records, sheet, nil
+}
+
+// excelDateToString переводит дату, хранящуюся в Excel числом, в формат 2006-01-02.
+// Текстовые значения возвращаются без изменений и проверяются как обычно
+func excelDateToString(value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+
+	date, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return value
+	}
+	return date.Format("2006-01-02")
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/tenant"
+	"itmo-devops-fp1/internal/types"
+	"strings"
+)
+
+// Префикс, по которому API-ключи легко узнать в конфигурации клиентов
+const apiKeyPrefix = "pk_"
+
+// Сколько первых символов ключа сохраняется для его опознания в списке
+const apiKeyVisiblePrefix = len(apiKeyPrefix) + 6
+
+// Ключ или токен не переданы, недействительны или отозваны
+var ErrUnauthorized = errors.New("требуется действующий API-ключ или токен")
+
+// Создает API-ключ с правами scopes, привязанный к арендатору tenantId (если он указан).
+// Сам ключ возвращается только здесь, в базе данных сохраняется его хеш
+func CreateAPIKey(ctx context.Context, name, tenantId string, scopes []types.Scope) (string, types.APIKey, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", types.APIKey{}, fmt.Errorf("%w: не указано имя ключа", ErrInvalidRecord)
+	}
+	if len(scopes) == 0 {
+		return "", types.APIKey{}, fmt.Errorf("%w: не указаны права ключа", ErrInvalidRecord)
+	}
+	if tenantId != "" {
+		if err := tenant.Validate(tenantId); err != nil {
+			return "", types.APIKey{}, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", types.APIKey{}, fmt.Errorf("не удалось создать ключ: %w", err)
+	}
+	token := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key, err := repository.CreateAPIKey(ctx, types.APIKey{
+		Name:   name,
+		Prefix: token[:apiKeyVisiblePrefix],
+		Scopes: scopes,
+		Tenant: tenantId,
+	}, hashAPIKey(token))
+	if err != nil {
+		return "", types.APIKey{}, err
+	}
+	return token, key, nil
+}
+
+// Возвращает все API-ключи
+func ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.FetchAPIKeys(ctx)
+}
+
+// Отзывает API-ключ по имени
+func RevokeAPIKey(ctx context.Context, name string) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.RevokeAPIKey(ctx, name)
+}
+
+// Проверяет API-ключ и возвращает клиента с правами ключа
+func AuthenticateAPIKey(ctx context.Context, token string) (types.Principal, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		return types.Principal{}, ErrUnauthorized
+	}
+
+	key, err := repository.FindAPIKey(ctx, hashAPIKey(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return types.Principal{}, ErrUnauthorized
+	}
+	if err != nil {
+		return types.Principal{}, err
+	}
+	return types.Principal{Subject: "apikey:" + key.Name, Scopes: key.Scopes, Tenant: key.Tenant}, nil
+}
+
+// Разбирает права через запятую
+func ParseScopes(value string) ([]types.Scope, error) {
+	var scopes []types.Scope
+	for _, item := range strings.Split(value, ",") {
+		switch scope := types.Scope(strings.TrimSpace(item)); scope {
+		case types.ScopeRead, types.ScopeWrite, types.ScopeAdmin:
+			scopes = append(scopes, scope)
+		case "":
+		default:
+			return nil, fmt.Errorf("%w: неизвестное право %q", ErrInvalidRecord, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// Ключи случайные и длинные, поэтому достаточно SHA-256 без соли
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
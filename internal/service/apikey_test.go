@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"reflect"
+	"testing"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	token := "pk_secret"
+	hash := hashAPIKey(token)
+
+	// SHA-256 от "pk_secret" в шестнадцатеричном виде
+	if want := "bdad83d6a6f8728d8548dd5ff71e5336f245f0ac008cffa67b482699105619ae"; hash != want {
+		t.Errorf("хеш %s, ожидался %s", hash, want)
+	}
+	if hash == hashAPIKey(token+"x") {
+		t.Error("хеш не зависит от ключа")
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []types.Scope
+		wantErr bool
+	}{
+		{"single", "read", []types.Scope{types.ScopeRead}, false},
+		{"several_with_spaces", "read, write ,admin", []types.Scope{types.ScopeRead, types.ScopeWrite, types.ScopeAdmin}, false},
+		{"empty_items_skipped", "read,,", []types.Scope{types.ScopeRead}, false},
+		{"empty", "", nil, false},
+		{"unknown", "read,delete", nil, true},
+		{"case_sensitive", "Read", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := ParseScopes(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecord) {
+					t.Errorf("err = %v, ожидалась ErrInvalidRecord", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(scopes, tt.want) {
+				t.Errorf("права %v, ожидались %v", scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []types.Scope
+		scope  types.Scope
+		want   bool
+	}{
+		{"granted", []types.Scope{types.ScopeRead}, types.ScopeRead, true},
+		{"missing", []types.Scope{types.ScopeRead}, types.ScopeWrite, false},
+		{"admin_includes_read", []types.Scope{types.ScopeAdmin}, types.ScopeRead, true},
+		{"admin_includes_write", []types.Scope{types.ScopeAdmin}, types.ScopeWrite, true},
+		{"write_excludes_admin", []types.Scope{types.ScopeRead, types.ScopeWrite}, types.ScopeAdmin, false},
+		{"no_scopes", nil, types.ScopeRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScope(types.Principal{Scopes: tt.scopes}, tt.scope); got != tt.want {
+				t.Errorf("HasScope = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Проверки, не доходящие до базы данных
+func TestAPIKeyValidation(t *testing.T) {
+	if _, err := AuthenticateAPIKey(context.Background(), "not-a-key"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ключ без префикса: err = %v, ожидалась ErrUnauthorized", err)
+	}
+
+	tests := []struct {
+		name     string
+		keyName  string
+		tenantId string
+		scopes   []types.Scope
+	}{
+		{"no_name", "  ", "", []types.Scope{types.ScopeRead}},
+		{"no_scopes", "ci", "", nil},
+		{"invalid_tenant", "ci", "../etc", []types.Scope{types.ScopeRead}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := CreateAPIKey(context.Background(), tt.keyName, tt.tenantId, tt.scopes); !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("err = %v, ожидалась ErrInvalidRecord", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"itmo-devops-fp1/internal/types"
+)
+
+// Ключ клиента в контексте запроса
+type principalKey struct{}
+
+// WithPrincipal сохраняет в контексте клиента, прошедшего проверку
+func WithPrincipal(ctx context.Context, principal types.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает клиента запроса; ok равен false, если проверка клиентов выключена
+func PrincipalFromContext(ctx context.Context) (types.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(types.Principal)
+	return principal, ok
+}
+
+// HasScope проверяет право клиента; право admin включает все остальные
+func HasScope(principal types.Principal, scope types.Scope) bool {
+	for _, s := range principal.Scopes {
+		if s == scope || s == types.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
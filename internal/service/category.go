@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"strings"
+)
+
+// Возвращает каталог категорий
+func GetCategories(ctx context.Context) ([]types.Category, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.FetchCategories(ctx)
+}
+
+// Возвращает категорию по идентификатору
+func GetCategory(ctx context.Context, id int) (types.Category, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.GetCategory(ctx, id)
+}
+
+// Создает категорию
+func CreateCategory(ctx context.Context, category types.Category) (types.Category, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	if err := validateCategory(&category); err != nil {
+		return types.Category{}, err
+	}
+	return repository.CreateCategory(ctx, category)
+}
+
+// Заменяет название, родителя и синонимы категории
+func UpdateCategory(ctx context.Context, id int, category types.Category) (types.Category, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	category.Id = id
+	if err := validateCategory(&category); err != nil {
+		return types.Category{}, err
+	}
+	return repository.UpdateCategory(ctx, category)
+}
+
+// Удаляет категорию
+func DeleteCategory(ctx context.Context, id int) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.DeleteCategory(ctx, id)
+}
+
+// Проверяет название категории и убирает из него лишние пробелы
+func validateCategory(category *types.Category) error {
+	category.Name = strings.Join(strings.Fields(category.Name), " ")
+	if category.Name == "" {
+		return fmt.Errorf("%w: не указано название категории", ErrInvalidRecord)
+	}
+	if category.ParentId != nil && *category.ParentId == category.Id {
+		return fmt.Errorf("%w: категория не может быть вложена в себя", repository.ErrInvalidParent)
+	}
+	return nil
+}
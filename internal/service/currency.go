@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"os"
+)
+
+// Загружает курсы валют из CSV-файла в поле формы file
+func ProcessRatesUpload(r *http.Request) (int, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Upload)
+	defer cancel()
+
+	file, err := getUploadedFile(r)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	ratesFile, err := os.CreateTemp("", "rates-*.csv")
+	if err != nil {
+		return 0, errors.New("не удалось создать файл курсов")
+	}
+	defer os.Remove(ratesFile.Name())
+	defer ratesFile.Close()
+
+	if _, err := io.Copy(ratesFile, file); err != nil {
+		return 0, errors.New("не удалось сохранить файл")
+	}
+
+	return repository.ProcessRatesCSV(ctx, ratesFile.Name())
+}
+
+// Возвращает курсы валют с необязательным фильтром currency
+func GetRates(r *http.Request) ([]types.ExchangeRate, error) {
+	ctx, cancel := queryContext(r.Context())
+	defer cancel()
+
+	currency, err := parseCurrency(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	return repository.FetchRates(ctx, currency)
+}
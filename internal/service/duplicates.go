@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Возвращает группы дубликатов
+func GetDuplicates(ctx context.Context) ([]types.DuplicateGroup, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.FetchDuplicateGroups(ctx)
+}
+
+// Удаляет дубликаты по правилу из параметра survivor (по умолчанию lowest_id).
+// По умолчанию возвращается только план; удаление выполняется лишь при явном dry_run=false
+func Dedupe(r *http.Request) (types.DedupeResponse, error) {
+	ctx, cancel := queryContext(r.Context())
+	defer cancel()
+
+	survivor, dryRun, err := parseDedupeParams(r.URL.Query())
+	if err != nil {
+		return types.DedupeResponse{}, err
+	}
+
+	response, err := repository.Dedupe(ctx, survivor, dryRun)
+	if err == nil && !dryRun {
+		logging.FromContext(r.Context()).Info("дубликаты удалены", "survivor", survivor, "groups", len(response.Groups), "removed", response.RemovedCount)
+	}
+	return response, err
+}
+
+// Разбирает параметры survivor и dry_run; без dry_run=false удаление не выполняется
+func parseDedupeParams(query url.Values) (types.SurvivorRule, bool, error) {
+	survivor := types.SurvivorRule(query.Get("survivor"))
+	switch survivor {
+	case "":
+		survivor = types.SurvivorLowestId
+	case types.SurvivorLowestId, types.SurvivorEarliestDate, types.SurvivorLatestDate:
+	default:
+		return "", false, fmt.Errorf("%w: неизвестное правило выбора записи %q", ErrInvalidFilter, survivor)
+	}
+
+	dryRun := true
+	if value := query.Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", false, fmt.Errorf("%w: неверное значение dry_run", ErrInvalidFilter)
+		}
+		dryRun = parsed
+	}
+	return survivor, dryRun, nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"net/url"
+	"testing"
+)
+
+func TestParseDedupeParams(t *testing.T) {
+	tests := []struct {
+		query        string
+		wantSurvivor types.SurvivorRule
+		wantDryRun   bool
+		wantErr      error
+	}{
+		{"", types.SurvivorLowestId, true, nil},
+		{"dry_run=true", types.SurvivorLowestId, true, nil},
+		{"dry_run=false", types.SurvivorLowestId, false, nil},
+		{"dry_run=0", types.SurvivorLowestId, false, nil},
+		{"survivor=latest_date", types.SurvivorLatestDate, true, nil},
+		{"survivor=earliest_date&dry_run=false", types.SurvivorEarliestDate, false, nil},
+		{"dry_run=maybe", "", false, ErrInvalidFilter},
+		{"survivor=newest", "", false, ErrInvalidFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			survivor, dryRun, err := parseDedupeParams(query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, ожидалось %v", err, tt.wantErr)
+			}
+			if survivor != tt.wantSurvivor || dryRun != tt.wantDryRun {
+				t.Errorf("survivor = %q, dry_run = %v; ожидалось %q, %v", survivor, dryRun, tt.wantSurvivor, tt.wantDryRun)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Сколько ждать ответа базы данных при проверке готовности
+const readinessTimeout = 2 * time.Second
+
+// Сервер останавливается и не принимает новые запросы
+var errDraining = errors.New("сервер останавливается")
+
+// Признак остановки сервера: новые запросы на него больше не направляются
+var draining atomic.Bool
+
+// SetDraining отмечает, что сервер останавливается
+func SetDraining() {
+	draining.Store(true)
+}
+
+// CheckReadiness проверяет, может ли сервер обслуживать загрузки:
+// база доступна, миграции применены, во временные файлы можно писать и сервер не останавливается
+func CheckReadiness(ctx context.Context) types.HealthResponse {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := []types.HealthCheck{
+		healthCheck("database", repository.PingDB(ctx)),
+		healthCheck("migrations", repository.CheckSchema(ctx)),
+		healthCheck("temp_dir", checkTempDir()),
+		healthCheck("draining", checkDraining()),
+	}
+
+	response := types.HealthResponse{Status: types.HealthOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != types.HealthOK {
+			response.Status = types.HealthFail
+		}
+	}
+	return response
+}
+
+// Временные файлы загрузок и выгрузок создаются в рабочей директории
+func checkTempDir() error {
+	file, err := os.CreateTemp(".", ".readyz-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+func checkDraining() error {
+	if draining.Load() {
+		return errDraining
+	}
+	return nil
+}
+
+func healthCheck(name string, err error) types.HealthCheck {
+	if err != nil {
+		return types.HealthCheck{Name: name, Status: types.HealthFail, Error: err.Error()}
+	}
+	return types.HealthCheck{Name: name, Status: types.HealthOK}
+}
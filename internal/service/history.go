@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+)
+
+// Возвращает товары с фильтрами sku, name и category
+func GetProductIdentities(r *http.Request) ([]types.ProductIdentity, error) {
+	ctx, cancel := queryContext(r.Context())
+	defer cancel()
+
+	query := r.URL.Query()
+	return repository.FetchProductIdentities(ctx, query.Get("sku"), query.Get("name"), query.Get("category"))
+}
+
+// Возвращает историю цен товара
+func GetPriceHistory(ctx context.Context, productId int) (types.PriceHistory, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.FetchPriceHistory(ctx, productId)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/metrics"
+	"itmo-devops-fp1/internal/tracing"
+	"itmo-devops-fp1/internal/types"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Строка Parquet-файла с типизированными колонками
+type parquetProduct struct {
+	Id        int64  `parquet:"id"`
+	CreatedAt int32  `parquet:"created_at,date"`
+	Name      string `parquet:"name"`
+	Category  string `parquet:"category"`
+	Price     int64  `parquet:"price,decimal(2:18)"`
+	Currency  string `parquet:"currency"`
+}
+
+// Источник продуктов, передающий их по одному в handle
+type productStream func(handle func(types.Product) error) error
+
+// Считает байты, уже отправленные клиенту
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+// Отправляет продукты клиенту в формате Parquet, сбрасывая каждую
+// группу из limits.ParquetRowGroupSize строк сразу в ответ.
+// Ошибка до отправки первого байта возвращается обработчику; после этого ответ
+// уже начат, поэтому соединение разрывается, чтобы клиент не принял обрезанный файл
+func serveParquet(ctx context.Context, w http.ResponseWriter, stream productStream) (err error) {
+	_, span := tracing.Start(ctx, "serveParquet")
+	rows := 0
+	out := &countingWriter{w: w}
+	defer func() {
+		span.SetAttributes(attribute.Int("rows", rows))
+		tracing.End(span, err)
+		if err != nil && out.written > 0 {
+			logging.FromContext(ctx).Error("выгрузка Parquet прервана после начала ответа", "error", err, "rows", rows)
+			panic(http.ErrAbortHandler)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	w.Header().Set("Content-Disposition", "attachment; filename=data.parquet")
+
+	writer := parquet.NewGenericWriter[parquetProduct](out)
+	batch := make([]parquetProduct, 0, limits.ParquetRowGroupSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := writer.Write(batch); err != nil {
+			return fmt.Errorf("не удалось записать в Parquet: %w", err)
+		}
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("не удалось записать группу строк Parquet: %w", err)
+		}
+		metrics.ExportRows.WithLabelValues(string(types.ExportParquet)).Add(float64(len(batch)))
+		rows += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err = stream(func(product types.Product) error {
+		batch = append(batch, toParquetProduct(product))
+		if len(batch) == limits.ParquetRowGroupSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("не удалось завершить Parquet файл: %w", err)
+	}
+	return nil
+}
+
+// Преобразует продукт в строку Parquet: дата в днях от эпохи, цена в копейках
+func toParquetProduct(product types.Product) parquetProduct {
+	return parquetProduct{
+		Id:        int64(product.Id),
+		CreatedAt: int32(daysSinceEpoch(product.CreatedAt)),
+		Name:      product.Name,
+		Category:  product.Category,
+		Price:     int64(math.Round(product.Price * 100)),
+		Currency:  product.Currency,
+	}
+}
+
+// Возвращает номер дня от эпохи Unix с округлением вниз, чтобы даты до 1970 года
+// не смещались на день вперед
+func daysSinceEpoch(t time.Time) int64 {
+	const secondsPerDay = int64(24 * time.Hour / time.Second)
+	seconds := t.Unix()
+	days := seconds / secondsPerDay
+	if seconds%secondsPerDay < 0 {
+		days--
+	}
+	return days
+}
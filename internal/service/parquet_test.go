@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDaysSinceEpoch(t *testing.T) {
+	tests := []struct {
+		date string
+		want int64
+	}{
+		{"1970-01-01", 0},
+		{"1970-01-02", 1},
+		{"1969-12-31", -1},
+		{"1900-01-01", -25567},
+		{"2024-01-01", 19723},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, err := time.Parse("2006-01-02", tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := daysSinceEpoch(date); got != tt.want {
+				t.Errorf("daysSinceEpoch(%s) = %d, ожидалось %d", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeParquetStreamError(t *testing.T) {
+	saved := limits
+	t.Cleanup(func() { limits = saved })
+	limits.ParquetRowGroupSize = 1
+
+	errStream := errors.New("ошибка чтения")
+	product := types.Product{Id: 1, Name: "item1", Category: "cat1", Price: 100, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name      string
+		rows      int
+		wantAbort bool
+	}{
+		// Записанные группы строк попадают в ответ после заполнения буфера писателя Parquet
+		{"before_first_byte", 0, false},
+		{"after_first_bytes", 1000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := func(handle func(types.Product) error) error {
+				for i := 0; i < tt.rows; i++ {
+					if err := handle(product); err != nil {
+						return err
+					}
+				}
+				return errStream
+			}
+
+			var err error
+			aborted := func() (aborted bool) {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						if recovered != http.ErrAbortHandler {
+							panic(recovered)
+						}
+						aborted = true
+					}
+				}()
+				err = serveParquet(context.Background(), httptest.NewRecorder(), stream)
+				return false
+			}()
+
+			if aborted != tt.wantAbort {
+				t.Fatalf("обрыв соединения = %v, ожидалось %v", aborted, tt.wantAbort)
+			}
+			if !tt.wantAbort && !errors.Is(err, errStream) {
+				t.Errorf("err = %v, ожидалась ошибка потока", err)
+			}
+		})
+	}
+}
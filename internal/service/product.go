@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"strconv"
+	"strings"
+)
+
+// Версия товара не совпадает с указанной в If-Match
+var ErrPreconditionFailed = errors.New("товар был изменен другим запросом")
+
+// Изменение или удаление товара без заголовка If-Match
+var ErrPreconditionRequired = errors.New("для изменения и удаления товара нужен заголовок If-Match")
+
+// Возвращает товар по идентификатору
+func GetProduct(ctx context.Context, id int) (types.Product, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.GetProduct(ctx, id)
+}
+
+// Создает товар из JSON-объекта
+func CreateProduct(ctx context.Context, object map[string]json.RawMessage) (types.Product, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	product, err := validateRecord(repository.ApplyJSONToRecord(repository.NewRecord(), object))
+	if err != nil {
+		return types.Product{}, err
+	}
+
+	return repository.CreateProduct(ctx, product)
+}
+
+// Полностью заменяет товар данными из JSON-объекта; версия товара в ifMatch обязательна
+func ReplaceProduct(ctx context.Context, id int, object map[string]json.RawMessage, ifMatch string) (types.Product, error) {
+	if ifMatch == "" {
+		return types.Product{}, ErrPreconditionRequired
+	}
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.UpdateProduct(ctx, id, func(current types.Product) (types.Product, error) {
+		if err := checkIfMatch(current, ifMatch); err != nil {
+			return types.Product{}, err
+		}
+		return validateRecordForId(id, repository.ApplyJSONToRecord(repository.NewRecord(), object))
+	})
+}
+
+// Изменяет только переданные в JSON-объекте поля товара; версия товара в ifMatch обязательна
+func PatchProduct(ctx context.Context, id int, object map[string]json.RawMessage, ifMatch string) (types.Product, error) {
+	if ifMatch == "" {
+		return types.Product{}, ErrPreconditionRequired
+	}
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.UpdateProduct(ctx, id, func(current types.Product) (types.Product, error) {
+		if err := checkIfMatch(current, ifMatch); err != nil {
+			return types.Product{}, err
+		}
+		return validateRecordForId(id, repository.ApplyJSONToRecord(repository.ProductToRecord(current), object))
+	})
+}
+
+// Удаляет товар; версия товара в ifMatch обязательна
+func DeleteProduct(ctx context.Context, id int, ifMatch string) error {
+	if ifMatch == "" {
+		return ErrPreconditionRequired
+	}
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.DeleteProduct(ctx, id, func(current types.Product) error {
+		return checkIfMatch(current, ifMatch)
+	})
+}
+
+// Вычисляет ETag по содержимому товара
+func ProductETag(product types.Product) string {
+	hash := sha256.Sum256([]byte(strings.Join(repository.ProductToRecord(product), "\x00")))
+	return `"` + hex.EncodeToString(hash[:8]) + `"`
+}
+
+// Преобразует товар в JSON-представление
+func ToProductJSON(product types.Product) types.ProductJSON {
+	return types.ProductJSON{
+		Id:        product.Id,
+		Name:      product.Name,
+		Category:  product.Category,
+		Price:     product.Price,
+		Currency:  product.Currency,
+		CreatedAt: product.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+// Проверяет заголовок If-Match; без заголовка изменение не выполняется
+func checkIfMatch(current types.Product, ifMatch string) error {
+	if ifMatch == "" {
+		return ErrPreconditionRequired
+	}
+
+	etag := ProductETag(current)
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return nil
+		}
+	}
+	return ErrPreconditionFailed
+}
+
+// Проверяет запись по правилам загрузки
+func validateRecord(record []string) (types.Product, error) {
+	product, err := repository.MapRecordToProduct(record)
+	if err != nil {
+		return types.Product{}, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+	return product, nil
+}
+
+// Проверяет запись, Id которой задан адресом запроса
+func validateRecordForId(id int, record []string) (types.Product, error) {
+	if record[0] != "" && record[0] != strconv.Itoa(id) {
+		return types.Product{}, fmt.Errorf("%w: Id в теле запроса не совпадает с адресом", ErrInvalidRecord)
+	}
+	record[0] = strconv.Itoa(id)
+	return validateRecord(record)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"testing"
+	"time"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	current := types.Product{Id: 1, Name: "item1", Category: "cat1", Price: 100, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Currency: "RUB"}
+	etag := ProductETag(current)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		wantErr error
+	}{
+		{"empty", "", ErrPreconditionRequired},
+		{"exact", etag, nil},
+		{"weak", "W/" + etag, nil},
+		{"any", "*", nil},
+		{"list", `"0000000000000000", ` + etag, nil},
+		{"stale", `"0000000000000000"`, ErrPreconditionFailed},
+		{"unquoted", etag[1 : len(etag)-1], ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkIfMatch(current, tt.ifMatch); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkIfMatch(%q) = %v, ожидалось %v", tt.ifMatch, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProductETagChangesWithContent(t *testing.T) {
+	product := types.Product{Id: 1, Name: "item1", Category: "cat1", Price: 100, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	changed := product
+	changed.Price = 101
+
+	if ProductETag(product) == ProductETag(changed) {
+		t.Error("ETag не изменился при изменении цены")
+	}
+	if ProductETag(product) != ProductETag(product) {
+		t.Error("ETag одного и того же товара различается")
+	}
+}
+
+func TestIfMatchRequired(t *testing.T) {
+	// Без If-Match запрос отклоняется до обращения к базе данных
+	if _, err := ReplaceProduct(context.Background(), 1, nil, ""); !errors.Is(err, ErrPreconditionRequired) {
+		t.Errorf("ReplaceProduct без If-Match: %v, ожидалась ErrPreconditionRequired", err)
+	}
+	if _, err := PatchProduct(context.Background(), 1, nil, ""); !errors.Is(err, ErrPreconditionRequired) {
+		t.Errorf("PatchProduct без If-Match: %v, ожидалась ErrPreconditionRequired", err)
+	}
+	if err := DeleteProduct(context.Background(), 1, ""); !errors.Is(err, ErrPreconditionRequired) {
+		t.Errorf("DeleteProduct без If-Match: %v, ожидалась ErrPreconditionRequired", err)
+	}
+}
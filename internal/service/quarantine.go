@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+)
+
+// Исправленная строка не прошла проверку
+var ErrInvalidRecord = errors.New("некорректная запись")
+
+// Возвращает строки из карантина
+func GetQuarantine(ctx context.Context) ([]types.QuarantinedRecord, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.FetchQuarantine(ctx)
+}
+
+// Применяет исправления к строке из карантина и переносит ее в таблицу цен
+func PromoteQuarantined(ctx context.Context, id int, fix types.QuarantineFix) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	quarantined, err := repository.GetQuarantined(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	product, err := validateRecord(applyFix(quarantined.RawRecord, fix))
+	if err != nil {
+		return err
+	}
+
+	return repository.PromoteQuarantined(ctx, id, product)
+}
+
+// Удаляет строку из карантина
+func DeleteQuarantined(ctx context.Context, id int) error {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.DeleteQuarantined(ctx, id)
+}
+
+// Очищает карантин целиком или для одного исходного файла
+func PurgeQuarantine(ctx context.Context, sourceFile string) (int64, error) {
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+	return repository.PurgeQuarantine(ctx, sourceFile)
+}
+
+// Подставляет исправленные значения в исходную CSV-строку
+func applyFix(raw []string, fix types.QuarantineFix) []string {
+	record := repository.NewRecord()
+	copy(record, raw)
+
+	fields := []*string{fix.Id, fix.Name, fix.Category, fix.Price, fix.CreatedAt, fix.Sku, fix.Currency}
+	for i, value := range fields {
+		if value != nil {
+			record[i] = *value
+		}
+	}
+
+	return record
+}
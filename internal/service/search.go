@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Ищет товары по строке q с необязательными фильтрами выгрузки и ограничением limit
+func SearchProducts(r *http.Request) ([]types.SearchResult, error) {
+	ctx, cancel := queryContext(r.Context())
+	defer cancel()
+
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		return nil, fmt.Errorf("%w: не указана строка поиска", ErrInvalidFilter)
+	}
+
+	filter, err := parseFilter(query, false)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := limits.SearchDefault
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > limits.SearchMax {
+			return nil, fmt.Errorf("%w: limit должен быть от 1 до %d", ErrInvalidFilter, limits.SearchMax)
+		}
+	}
+
+	products, ranks, err := repository.SearchProducts(ctx, q, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]types.SearchResult, len(products))
+	for i, product := range products {
+		results[i] = types.SearchResult{ProductJSON: ToProductJSON(product), Rank: ranks[i]}
+	}
+	return results, nil
+}
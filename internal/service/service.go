@@ -2,129 +2,379 @@ package service
 
 import (
 	"archive/zip"
+	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"itmo-devops-fp1/internal/config"
+	"itmo-devops-fp1/internal/logging"
+	"itmo-devops-fp1/internal/metrics"
 	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/tracing"
 	"itmo-devops-fp1/internal/types"
+	"itmo-devops-fp1/pkg/utils"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Обрабатывает загрузку данных из архива
-func ProcessUpload(r *http.Request, archiveType types.ArchiveType) (types.GetPricesResponse, error) {
+// Заголовок, в котором клиент передает ключ идемпотентности
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Ключ идемпотентности повторно использован с другим содержимым
+var ErrIdempotencyConflict = repository.ErrIdempotencyConflict
+
+// Тело загрузки больше limits.MaxUploadBytes
+var ErrUploadTooLarge = errors.New("превышен размер загрузки")
+
+// Ограничения на размеры ответов и загрузок
+var limits = config.Default().Limits
+
+// Ограничения времени операций с базой данных
+var timeouts = config.Default().Timeouts
+
+// Configure применяет ограничения из конфигурации
+func Configure(cfg config.Config) {
+	limits = cfg.Limits
+	timeouts = cfg.Timeouts
+}
+
+// Обрабатывает загрузку данных из архива.
+// Повторная загрузка того же архива или с тем же ключом идемпотентности
+// не обрабатывается заново: возвращается исходный ответ и признак повтора.
+func ProcessUpload(r *http.Request, archiveType types.ArchiveType) (response types.GetPricesResponse, replayed bool, err error) {
+	ctx, span := tracing.Start(r.Context(), "ProcessUpload", attribute.String("upload.type", string(archiveType)))
+	defer func() { tracing.End(span, err) }()
+
+	r.Body = http.MaxBytesReader(nil, r.Body, int64(limits.MaxUploadBytes))
 	file, err := getUploadedFile(r)
 	if err != nil {
-		return types.GetPricesResponse{}, err
+		return types.GetPricesResponse{}, false, err
 	}
 	defer file.Close()
 
 	ext := ".zip"
-	if archiveType == types.Tar {
+	switch archiveType {
+	case types.Tar:
 		ext = ".tar"
+	case types.Xlsx:
+		ext = ".xlsx"
+	}
+
+	// Для XLSX результат зависит от выбранного листа, поэтому он входит в хеш загрузки
+	sheet := r.URL.Query().Get("sheet")
+	variant := ""
+	if archiveType == types.Xlsx {
+		variant = sheet
+	}
+
+	return processIdempotentUpload(ctx, r, file, "upload-*"+ext, variant, func(ctx context.Context, filename string, upload types.Upload) (types.GetPricesResponse, bool, error) {
+		if archiveType == types.Xlsx {
+			return repository.ProcessXLSX(ctx, filename, sheet, upload)
+		}
+		return processArchive(ctx, filename, archiveType, upload)
+	})
+}
+
+// Обрабатывает загрузку товаров из тела запроса в формате JSON или NDJSON.
+// Идемпотентность работает так же, как для архивов.
+func ProcessJSONUpload(r *http.Request, format types.BodyFormat) (response types.GetPricesResponse, replayed bool, err error) {
+	defer r.Body.Close()
+
+	ctx, span := tracing.Start(r.Context(), "ProcessJSONUpload", attribute.String("upload.type", string(format)))
+	defer func() { tracing.End(span, err) }()
+
+	body := http.MaxBytesReader(nil, r.Body, int64(limits.MaxUploadBytes))
+	return processIdempotentUpload(ctx, r, body, "upload-*.json", "", func(ctx context.Context, filename string, upload types.Upload) (types.GetPricesResponse, bool, error) {
+		return repository.ProcessJSONFile(ctx, filename, format, upload)
+	})
+}
+
+// Сохраняет тело загрузки в отдельный временный файл с именем по шаблону pattern, проверяет, не обрабатывалось ли оно раньше,
+// и при необходимости обрабатывает его функцией process.
+// Предварительная проверка лишь избавляет от разбора файла при повторе: окончательно
+// повтор определяется в транзакции загрузки, где запись о ней сохраняется до обработки строк.
+// variant добавляется к хешу, если результат зависит не только от содержимого.
+// Обработка ограничена временем timeouts.Upload и прерывается при отключении клиента,
+// при этом транзакция загрузки откатывается
+func processIdempotentUpload(
+	ctx context.Context,
+	r *http.Request,
+	body io.Reader,
+	pattern string,
+	variant string,
+	process func(ctx context.Context, filename string, upload types.Upload) (types.GetPricesResponse, bool, error),
+) (types.GetPricesResponse, bool, error) {
+	// У каждой загрузки свой файл, поэтому одновременные загрузки не перезаписывают друг друга
+	uploadFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return types.GetPricesResponse{}, false, errors.New("не удалось создать файл загрузки")
+	}
+	defer os.Remove(uploadFile.Name())
+	defer uploadFile.Close()
+
+	// Считаем хеш содержимого одновременно с сохранением файла
+	hasher := sha256.New()
+	if variant != "" {
+		io.WriteString(hasher, variant+"\n")
+	}
+	size, err := io.Copy(io.MultiWriter(uploadFile, hasher), body)
+	if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+		return types.GetPricesResponse{}, false, fmt.Errorf("%w: предел %d байт", ErrUploadTooLarge, tooLarge.Limit)
+	}
+	if err != nil {
+		return types.GetPricesResponse{}, false, errors.New("не удалось сохранить файл")
+	}
+
+	upload := types.Upload{
+		IdempotencyKey: r.Header.Get(IdempotencyKeyHeader),
+		ContentHash:    hex.EncodeToString(hasher.Sum(nil)),
 	}
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		upload.Subject = principal.Subject
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Upload)
+	defer cancel()
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("upload.hash", upload.ContentHash), attribute.Int64("upload.bytes", size))
+
+	logger := logging.FromContext(ctx).With("content_hash", upload.ContentHash, "subject", upload.Subject)
 
-	archiveFile, err := os.Create("upload" + ext)
+	replay := func(response types.GetPricesResponse) (types.GetPricesResponse, bool, error) {
+		span.SetAttributes(attribute.Bool("upload.replayed", true))
+		logger.Info("повторная загрузка, возвращается исходный ответ")
+		return response, true, nil
+	}
+
+	previous, found, err := repository.FindPreviousUpload(ctx, upload)
 	if err != nil {
-		return types.GetPricesResponse{}, errors.New("не удалось создать файл архива")
+		return types.GetPricesResponse{}, false, err
 	}
-	defer os.Remove(archiveFile.Name())
+	if found {
+		return replay(previous.Response)
+	}
+
+	format := strings.TrimPrefix(filepath.Ext(pattern), ".")
+	metrics.UploadBytes.WithLabelValues(format).Add(float64(size))
 
-	if _, err := io.Copy(archiveFile, file); err != nil {
-		return types.GetPricesResponse{}, errors.New("не удалось сохранить файл")
+	var replayed bool
+	upload.Response, replayed, err = process(ctx, uploadFile.Name(), upload)
+	if err != nil {
+		return types.GetPricesResponse{}, false, err
+	}
+	if replayed {
+		return replay(upload.Response)
 	}
 
-	return processArchive(archiveFile.Name(), archiveType)
+	span.SetAttributes(
+		attribute.Int("rows.read", upload.Response.TotalCount),
+		attribute.Int("rows.inserted", upload.Response.TotalItems),
+		attribute.Int("rows.quarantined", upload.Response.QuarantinedCount),
+	)
+	metrics.UploadRows.WithLabelValues(format, metrics.RowsRead).Add(float64(upload.Response.TotalCount))
+	metrics.UploadRows.WithLabelValues(format, metrics.RowsInserted).Add(float64(upload.Response.TotalItems))
+	metrics.UploadRows.WithLabelValues(format, metrics.RowsRejected).Add(float64(upload.Response.QuarantinedCount))
+	logger.Info("загрузка обработана",
+		"format", format,
+		"bytes", size,
+		"rows_read", upload.Response.TotalCount,
+		"rows_inserted", upload.Response.TotalItems,
+		"rows_quarantined", upload.Response.QuarantinedCount,
+	)
+
+	return upload.Response, false, nil
 }
 
+// Запрошен неподдерживаемый формат выгрузки
+var ErrUnsupportedFormat = errors.New("неподдерживаемый формат выгрузки")
+
 // Обрабатывает скачивание данных
-func ProcessDownload(w http.ResponseWriter, r *http.Request) error {
-	products, err := fetchProducts()
+func ProcessDownload(w http.ResponseWriter, r *http.Request) (err error) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Export)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "ProcessDownload")
+	defer func() { tracing.End(span, err) }()
+
+	format, err := exportFormat(r)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attribute.String("export.format", string(format)))
 
-	csvFile, err := createCSV(products)
+	// Без фильтров из параметров учитывается только валюта пересчета
+	currency, err := parseCurrency(r.URL.Query())
 	if err != nil {
 		return err
 	}
-	defer os.Remove(csvFile.Name())
+	filter := types.PriceFilter{Currency: currency}
 
-	zipFile, err := createZipFromCSV(csvFile)
+	return serveExport(ctx, w, r, format, filter)
+}
+
+// Обрабатывает скачивание отфильтрованных данных
+func ProcessFilteredDownload(w http.ResponseWriter, r *http.Request) (err error) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Export)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "ProcessFilteredDownload")
+	defer func() { tracing.End(span, err) }()
+
+	format, err := exportFormat(r)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("export.format", string(format)))
+
+	// Получаем и валидируем параметры
+	filter, err := parseFilter(r.URL.Query(), true)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(zipFile.Name())
 
-	return serveZipFile(w, r, zipFile)
+	return serveExport(ctx, w, r, format, filter)
 }
 
-// Обрабатывает скачивание отфильтрованных данных
-func ProcessFilteredDownload(w http.ResponseWriter, r *http.Request) error {
-	// Получаем и валидируем параметры
-	start := r.URL.Query().Get("start")
-	end := r.URL.Query().Get("end")
-	minStr := r.URL.Query().Get("min")
-	maxStr := r.URL.Query().Get("max")
+// Отправляет данные по фильтру в выбранном формате. Строки читаются из базы потоком
+// и сразу записываются в файл выгрузки (Parquet — прямо в ответ), не накапливаясь в памяти
+func serveExport(ctx context.Context, w http.ResponseWriter, r *http.Request, format types.ExportFormat, filter types.PriceFilter) error {
+	// Колонка валюты добавляется в CSV по запросу, чтобы не менять привычный формат файла
+	withCurrency := false
+	if value := r.URL.Query().Get("currency_column"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%w: неверное значение currency_column", ErrInvalidFilter)
+		}
+		withCurrency = parsed
+	}
+	// Без пересчета цены в разных валютах нельзя различить без колонки валюты,
+	// поэтому она добавляется всегда, если валют в выборке больше одной
+	if format == types.ExportZip && !withCurrency && filter.Currency == "" {
+		queryCtx, cancel := queryContext(ctx)
+		currencies, err := repository.CountCurrencies(queryCtx, filter)
+		cancel()
+		if err != nil {
+			return err
+		}
+		withCurrency = currencies > 1
+	}
+
+	stream := func(handle func(types.Product) error) error {
+		return repository.StreamData(ctx, filter, handle)
+	}
+
+	switch format {
+	case types.ExportXlsx:
+		return serveProductsXLSX(ctx, w, r, stream)
+	case types.ExportParquet:
+		return serveParquet(ctx, w, stream)
+	}
+
+	// Отправляем CSV в ZIP архиве
+	return serveProductsZip(ctx, w, r, stream, withCurrency)
+}
+
+// Ограничивает запрос к базе данных временем timeouts.Query
+func queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, timeouts.Query)
+}
+
+// Параметры фильтра заданы неверно
+var ErrInvalidFilter = errors.New("неверные параметры фильтра")
+
+// Разбирает параметры фильтра start, end, min и max.
+// Если required равен false, отсутствующие параметры не ограничивают выборку
+func parseFilter(query url.Values, required bool) (types.PriceFilter, error) {
+	var filter types.PriceFilter
 
 	// Проверяем формат дат
-	if _, err := time.Parse("2006-01-02", start); err != nil {
-		return fmt.Errorf("неверный формат начальной даты: %w", err)
+	if start := query.Get("start"); start != "" || required {
+		if _, err := time.Parse("2006-01-02", start); err != nil {
+			return filter, fmt.Errorf("%w: неверный формат начальной даты: %v", ErrInvalidFilter, err)
+		}
+		filter.Start = start
 	}
-	if _, err := time.Parse("2006-01-02", end); err != nil {
-		return fmt.Errorf("неверный формат конечной даты: %w", err)
+	if end := query.Get("end"); end != "" || required {
+		if _, err := time.Parse("2006-01-02", end); err != nil {
+			return filter, fmt.Errorf("%w: неверный формат конечной даты: %v", ErrInvalidFilter, err)
+		}
+		filter.End = end
 	}
 
 	// Парсим min и max
-	min, err := strconv.ParseInt(minStr, 10, 64)
-	if err != nil || min <= 0 {
-		return errors.New("неверное значение минимальной цены")
+	if minStr := query.Get("min"); minStr != "" || required {
+		min, err := strconv.ParseInt(minStr, 10, 64)
+		if err != nil || min <= 0 {
+			return filter, fmt.Errorf("%w: неверное значение минимальной цены", ErrInvalidFilter)
+		}
+		filter.Min = float64(min)
 	}
-
-	max, err := strconv.ParseInt(maxStr, 10, 64)
-	if err != nil || max <= 0 {
-		return errors.New("неверное значение максимальной цены")
+	if maxStr := query.Get("max"); maxStr != "" || required {
+		max, err := strconv.ParseInt(maxStr, 10, 64)
+		if err != nil || max <= 0 {
+			return filter, fmt.Errorf("%w: неверное значение максимальной цены", ErrInvalidFilter)
+		}
+		filter.Max = float64(max)
 	}
 
-	if min > max {
-		return errors.New("минимальная цена не может быть больше максимальной")
+	if filter.Min > 0 && filter.Max > 0 && filter.Min > filter.Max {
+		return filter, fmt.Errorf("%w: минимальная цена не может быть больше максимальной", ErrInvalidFilter)
 	}
 
-	// Преобразуем в float64 для запроса к БД
-	minPrice := float64(min)
-	maxPrice := float64(max)
-
-	// Получаем отфильтрованные данные
-	products, err := repository.FetchFilteredData(start, end, minPrice, maxPrice)
+	currency, err := parseCurrency(query)
 	if err != nil {
-		return fmt.Errorf("ошибка получения данных: %w", err)
+		return filter, err
 	}
+	filter.Currency = currency
 
-	// Создаем CSV файл
-	csvFile, err := createCSV(products)
-	if err != nil {
-		return err
+	return filter, nil
+}
+
+// Разбирает необязательный параметр currency — валюту пересчета цен
+func parseCurrency(query url.Values) (string, error) {
+	value := query.Get("currency")
+	if value == "" {
+		return "", nil
 	}
-	defer os.Remove(csvFile.Name())
 
-	// Создаем ZIP архив
-	zipFile, err := createZipFromCSV(csvFile)
+	currency, err := utils.NormalizeCurrency(value)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("%w: %v", ErrInvalidFilter, err)
 	}
-	defer os.Remove(zipFile.Name())
+	return currency, nil
+}
 
-	// Отправляем файл клиенту
-	return serveZipFile(w, r, zipFile)
+// Получает формат выгрузки из параметра format (по умолчанию zip)
+func exportFormat(r *http.Request) (types.ExportFormat, error) {
+	switch format := types.ExportFormat(r.URL.Query().Get("format")); format {
+	case "":
+		return types.ExportZip, nil
+	case types.ExportZip, types.ExportXlsx, types.ExportParquet:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
 }
 
 // Получает загруженный файл из запроса
 func getUploadedFile(r *http.Request) (multipart.File, error) {
 	file, _, err := r.FormFile("file")
+	if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+		return nil, fmt.Errorf("%w: предел %d байт", ErrUploadTooLarge, tooLarge.Limit)
+	}
 	if err != nil {
 		return nil, errors.New("не удалось прочитать файл")
 	}
@@ -132,64 +382,77 @@ func getUploadedFile(r *http.Request) (multipart.File, error) {
 }
 
 // Обрабатывает архив в зависимости от типа
-func processArchive(filename string, archiveType types.ArchiveType) (types.GetPricesResponse, error) {
+func processArchive(ctx context.Context, filename string, archiveType types.ArchiveType, upload types.Upload) (response types.GetPricesResponse, replayed bool, err error) {
+	ctx, span := tracing.Start(ctx, "processArchive", attribute.String("archive.type", string(archiveType)))
+	defer func() { tracing.End(span, err) }()
+
 	if archiveType == types.Tar {
-		return repository.ProcessTar(filename)
+		return repository.ProcessTar(ctx, filename, upload)
 	}
-	return repository.ProcessZip(filename)
+	return repository.ProcessZip(ctx, filename, upload)
 }
 
-// Получает данные из репозитория
-func fetchProducts() ([]types.Product, error) {
-	products, err := repository.FetchData()
+// Создает CSV-файл с данными и возвращает его вместе с количеством строк
+func createCSV(stream productStream, withCurrency bool) (*os.File, int, error) {
+	csvFile, err := os.CreateTemp("", "data-*.csv")
 	if err != nil {
-		return nil, fmt.Errorf("не удалось получить продукты: %w", err)
+		return nil, 0, fmt.Errorf("не удалось создать CSV файл: %w", err)
 	}
-	return products, nil
-}
 
-// Создает CSV-файл с данными
-func createCSV(products []types.Product) (*os.File, error) {
-	csvFile, err := os.Create("data.csv")
+	rows, err := writeProductsToCSV(csvFile, stream, withCurrency)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось создать CSV файл: %w", err)
-	}
-
-	if err := writeProductsToCSV(csvFile, products); err != nil {
-		return nil, err
+		csvFile.Close()
+		os.Remove(csvFile.Name())
+		return nil, 0, err
 	}
 
-	return csvFile, nil
+	return csvFile, rows, nil
 }
 
-// Записывает продукты в CSV
-func writeProductsToCSV(file *os.File, products []types.Product) error {
+// Записывает продукты в CSV и возвращает количество строк.
+// Валюта записывается шестой колонкой, только если withCurrency равен true
+func writeProductsToCSV(file io.Writer, stream productStream, withCurrency bool) (int, error) {
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
-	for _, product := range products {
+	rows := 0
+	err := stream(func(product types.Product) error {
 		record := []string{
 			strconv.Itoa(product.Id),
 			product.Name,
 			product.Category,
 			strconv.FormatFloat(product.Price, 'f', 2, 64),
-			product.CreatedAt,
+			product.CreatedAt.Format("2006-01-02"),
+		}
+		if withCurrency {
+			record = append(record, product.Currency)
 		}
 		if err := writer.Write(record); err != nil {
 			return fmt.Errorf("не удалось записать в CSV: %w", err)
 		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return 0, fmt.Errorf("не удалось записать в CSV: %w", err)
+	}
+	return rows, nil
 }
 
 // Создает ZIP-архив из CSV-файла
 func createZipFromCSV(csvFile *os.File) (*os.File, error) {
-	zipFile, err := os.Create("data.zip")
+	zipFile, err := os.CreateTemp("", "data-*.zip")
 	if err != nil {
 		return nil, fmt.Errorf("не удалось создать ZIP файл: %w", err)
 	}
 
 	if err := addFileToZip(zipFile, csvFile); err != nil {
+		zipFile.Close()
+		os.Remove(zipFile.Name())
 		return nil, err
 	}
 
@@ -219,6 +482,37 @@ func addFileToZip(zipFile *os.File, fileToAdd *os.File) error {
 	return nil
 }
 
+// Формирует ZIP архив с CSV-файлом продуктов и отправляет его клиенту
+func serveProductsZip(ctx context.Context, w http.ResponseWriter, r *http.Request, stream productStream, withCurrency bool) error {
+	// Создаем CSV файл
+	_, span := tracing.Start(ctx, "createCSV")
+	csvFile, rows, err := createCSV(stream, withCurrency)
+	span.SetAttributes(attribute.Int("rows", rows))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(csvFile.Name())
+	defer csvFile.Close()
+
+	// Создаем ZIP архив
+	_, span = tracing.Start(ctx, "createZipFromCSV")
+	zipFile, err := createZipFromCSV(csvFile)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(zipFile.Name())
+	defer zipFile.Close()
+
+	metrics.ExportRows.WithLabelValues(string(types.ExportZip)).Add(float64(rows))
+
+	_, span = tracing.Start(ctx, "serveZipFile")
+	err = serveZipFile(w, r, zipFile)
+	tracing.End(span, err)
+	return err
+}
+
 // Отправляет ZIP-архив клиенту
 func serveZipFile(w http.ResponseWriter, r *http.Request, zipFile *os.File) error {
 	w.Header().Set("Content-Type", "application/zip")
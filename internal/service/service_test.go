@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"itmo-devops-fp1/internal/types"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProcessJSONUploadTooLarge(t *testing.T) {
+	saved := limits
+	t.Cleanup(func() { limits = saved })
+	limits.MaxUploadBytes = 16
+
+	r := httptest.NewRequest("POST", "/api/v0/prices", strings.NewReader(`[{"id": 1, "name": "item1"}]`))
+	r.Header.Set("Content-Type", string(types.JSON))
+
+	// Размер проверяется при сохранении тела, до обращения к базе данных
+	if _, _, err := ProcessJSONUpload(r, types.JSON); !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("err = %v, ожидалась ErrUploadTooLarge", err)
+	}
+}
+
+func TestWriteProductsToCSV(t *testing.T) {
+	products := []types.Product{
+		{Id: 1, Name: "item1", Category: "cat1", Price: 100, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Currency: "USD"},
+	}
+	stream := func(handle func(types.Product) error) error {
+		for _, product := range products {
+			if err := handle(product); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name         string
+		withCurrency bool
+		want         string
+	}{
+		{"default", false, "1,item1,cat1,100.00,2024-01-01\n"},
+		{"with_currency", true, "1,item1,cat1,100.00,2024-01-01,USD\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			rows, err := writeProductsToCSV(&out, stream, tt.withCurrency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rows != 1 || out.String() != tt.want {
+				t.Errorf("строк %d, CSV %q; ожидалось 1, %q", rows, out.String(), tt.want)
+			}
+		})
+	}
+}
+
+// productsStream возвращает поток из одного товара с названием name
+func productsStream(name string) productStream {
+	return func(handle func(types.Product) error) error {
+		return handle(types.Product{Id: 1, Name: name, Category: "cat1", Price: 100, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	}
+}
+
+func TestExportFilesNotShared(t *testing.T) {
+	names := []string{"tenant-a", "tenant-b"}
+	csvFiles := make([]string, len(names))
+	xlsxFiles := make([]string, len(names))
+	errs := make([]error, 2*len(names))
+
+	// Одновременные выгрузки пишут в разные файлы
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			file, _, err := createCSV(productsStream(name), false)
+			if err == nil {
+				file.Close()
+				csvFiles[i] = file.Name()
+			}
+			errs[2*i] = err
+		}()
+		go func() {
+			defer wg.Done()
+			xlsxFiles[i], _, errs[2*i+1] = createXLSX(productsStream(name))
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range append(csvFiles, xlsxFiles...) {
+		t.Cleanup(func() { os.Remove(file) })
+	}
+
+	if csvFiles[0] == csvFiles[1] || xlsxFiles[0] == xlsxFiles[1] {
+		t.Fatalf("выгрузки используют общий файл: %v, %v", csvFiles, xlsxFiles)
+	}
+	for i, name := range names {
+		data, err := os.ReadFile(csvFiles[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "1," + name + ",cat1,100.00,2024-01-01\n"; string(data) != want {
+			t.Errorf("CSV %q, ожидалось %q", data, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"fmt"
+	"itmo-devops-fp1/internal/repository"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+)
+
+// Возвращает статистику по ценам с фильтрами выгрузки и группировкой group_by
+func GetStatistics(r *http.Request) (types.StatisticsResponse, error) {
+	ctx, cancel := queryContext(r.Context())
+	defer cancel()
+
+	filter, err := parseFilter(r.URL.Query(), false)
+	if err != nil {
+		return types.StatisticsResponse{}, err
+	}
+
+	groupBy := types.GroupBy(r.URL.Query().Get("group_by"))
+	switch groupBy {
+	case types.GroupNone, types.GroupCategory, types.GroupDay, types.GroupMonth:
+	default:
+		return types.StatisticsResponse{}, fmt.Errorf("%w: неизвестная группировка %q", ErrInvalidFilter, groupBy)
+	}
+
+	groups, err := repository.FetchStatistics(ctx, filter, groupBy)
+	if err != nil {
+		return types.StatisticsResponse{}, err
+	}
+
+	return types.StatisticsResponse{GroupBy: groupBy, Currency: filter.Currency, Groups: groups}, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"itmo-devops-fp1/internal/metrics"
+	"itmo-devops-fp1/internal/tracing"
+	"itmo-devops-fp1/internal/types"
+	"net/http"
+	"os"
+
+	"github.com/xuri/excelize/v2"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Заголовок листа выгрузки, совпадает с ожидаемым при загрузке
+var xlsxHeader = []interface{}{"id", "name", "category", "price", "create_date", "currency"}
+
+// Формирует XLSX-файл с продуктами и отправляет его клиенту
+func serveProductsXLSX(ctx context.Context, w http.ResponseWriter, r *http.Request, stream productStream) error {
+	_, span := tracing.Start(ctx, "createXLSX")
+	xlsxFile, rows, err := createXLSX(stream)
+	span.SetAttributes(attribute.Int("rows", rows))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(xlsxFile)
+
+	metrics.ExportRows.WithLabelValues(string(types.ExportXlsx)).Add(float64(rows))
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=data.xlsx")
+	_, span = tracing.Start(ctx, "serveXLSXFile")
+	http.ServeFile(w, r, xlsxFile)
+	span.End()
+	return nil
+}
+
+// Создает XLSX-файл с данными и возвращает его имя и количество строк
+func createXLSX(stream productStream) (string, int, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	writer, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return "", 0, fmt.Errorf("не удалось создать лист XLSX: %w", err)
+	}
+
+	// Встроенный формат 14 отображает дату без времени
+	dateStyle, err := file.NewStyle(&excelize.Style{NumFmt: 14})
+	if err != nil {
+		return "", 0, fmt.Errorf("не удалось создать стиль даты: %w", err)
+	}
+
+	if err := writer.SetRow("A1", xlsxHeader); err != nil {
+		return "", 0, fmt.Errorf("не удалось записать заголовок XLSX: %w", err)
+	}
+
+	rows := 0
+	err = stream(func(product types.Product) error {
+		cell, err := excelize.CoordinatesToCellName(1, rows+2)
+		if err != nil {
+			return fmt.Errorf("не удалось вычислить адрес ячейки: %w", err)
+		}
+
+		row := []interface{}{
+			product.Id,
+			product.Name,
+			product.Category,
+			product.Price,
+			excelize.Cell{StyleID: dateStyle, Value: product.CreatedAt},
+			product.Currency,
+		}
+		if err := writer.SetRow(cell, row); err != nil {
+			return fmt.Errorf("не удалось записать в XLSX: %w", err)
+		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", 0, fmt.Errorf("не удалось записать в XLSX: %w", err)
+	}
+
+	// Отдельный файл для каждой выгрузки, чтобы одновременные выгрузки не перезаписывали друг друга
+	xlsxFile, err := os.CreateTemp("", "data-*.xlsx")
+	if err != nil {
+		return "", 0, fmt.Errorf("не удалось создать XLSX файл: %w", err)
+	}
+	defer xlsxFile.Close()
+
+	if err := file.Write(xlsxFile); err != nil {
+		os.Remove(xlsxFile.Name())
+		return "", 0, fmt.Errorf("не удалось сохранить XLSX файл: %w", err)
+	}
+
+	return xlsxFile.Name(), rows, nil
+}
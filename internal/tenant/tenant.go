@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Арендатор по умолчанию; ему принадлежат данные, загруженные до разделения по арендаторам
+const Default = "default"
+
+// Идентификатор арендатора не подходит по формату
+var ErrInvalid = errors.New("неверный идентификатор арендатора")
+
+// Идентификатор: латинские буквы, цифры, точка, дефис и подчеркивание, до 64 символов
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Ключ арендатора в контексте запроса
+type tenantKey struct{}
+
+// Validate проверяет формат идентификатора арендатора
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("%w: %q", ErrInvalid, id)
+	}
+	return nil
+}
+
+// WithTenant сохраняет арендатора запроса в контексте
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext возвращает арендатора запроса, а если он не задан — арендатора по умолчанию
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok {
+		return id
+	}
+	return Default
+}
@@ -1,24 +1,257 @@
 package types
 
+import "time"
+
 type ArchiveType string
 
 const (
-	Zip ArchiveType = "zip"
-	Tar ArchiveType = "tar"
+	Zip  ArchiveType = "zip"
+	Tar  ArchiveType = "tar"
+	Xlsx ArchiveType = "xlsx"
+)
+
+// Форматы выгрузки данных
+type ExportFormat string
+
+const (
+	ExportZip     ExportFormat = "zip"
+	ExportXlsx    ExportFormat = "xlsx"
+	ExportParquet ExportFormat = "parquet"
+)
+
+// Форматы тела запроса при загрузке без архива
+type BodyFormat string
+
+const (
+	JSON   BodyFormat = "application/json"
+	NDJSON BodyFormat = "application/x-ndjson"
 )
 
 type Product struct {
 	Id        int
-	CreatedAt string
+	CreatedAt time.Time
 	Name      string
 	Category  string
 	Price     float64
+	// Артикул поставщика, необязателен; используется для истории цен
+	Sku      string
+	Currency string
 }
 
-type GetPricesResponse struct {
+// Фильтр по дате создания и цене; пустые значения не ограничивают выборку
+type PriceFilter struct {
+	Start string
+	End   string
+	Min   float64
+	Max   float64
+	// Валюта, в которую пересчитываются цены; пустая — без пересчета
+	Currency string
+}
+
+// Способ группировки статистики
+type GroupBy string
+
+const (
+	GroupNone     GroupBy = ""
+	GroupCategory GroupBy = "category"
+	GroupDay      GroupBy = "day"
+	GroupMonth    GroupBy = "month"
+)
+
+// Статистика по группе товаров
+type PriceStatistics struct {
+	Group           string  `json:"group,omitempty"`
 	TotalCount      int     `json:"total_count"`
 	DuplicatesCount int     `json:"duplicates_count"`
 	TotalItems      int     `json:"total_items"`
-	TotalCategories int     `json:"total_categories"`
 	TotalPrice      float64 `json:"total_price"`
+	MinPrice        float64 `json:"min_price"`
+	MaxPrice        float64 `json:"max_price"`
+	AvgPrice        float64 `json:"avg_price"`
+	MedianPrice     float64 `json:"median_price"`
+}
+
+// Ответ эндпоинта статистики
+type StatisticsResponse struct {
+	GroupBy  GroupBy           `json:"group_by,omitempty"`
+	Currency string            `json:"currency,omitempty"`
+	Groups   []PriceStatistics `json:"groups"`
+}
+
+// Правило выбора остающейся записи при удалении дубликатов
+type SurvivorRule string
+
+const (
+	SurvivorLowestId     SurvivorRule = "lowest_id"
+	SurvivorEarliestDate SurvivorRule = "earliest_date"
+	SurvivorLatestDate   SurvivorRule = "latest_date"
+)
+
+// Группа записей с одинаковым ключом дубликата; отсутствующее значение поля — nil
+type DuplicateGroup struct {
+	Key map[string]*string `json:"key"`
+	Ids []int64            `json:"ids"`
+}
+
+// Результат удаления дубликатов в одной группе
+type DedupeGroup struct {
+	Key        map[string]*string `json:"key"`
+	SurvivorId int64              `json:"survivor_id"`
+	RemovedIds []int64            `json:"removed_ids"`
+}
+
+// Ответ операции удаления дубликатов
+type DedupeResponse struct {
+	DryRun       bool          `json:"dry_run"`
+	Survivor     SurvivorRule  `json:"survivor"`
+	RemovedCount int           `json:"removed_count"`
+	Groups       []DedupeGroup `json:"groups"`
+}
+
+// Товар как сущность: артикул поставщика либо пара название + категория
+type ProductIdentity struct {
+	Id       int    `json:"id"`
+	Sku      string `json:"sku,omitempty"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// Наблюдение цены товара
+type PriceObservation struct {
+	PriceId    int       `json:"price_id"`
+	Price      float64   `json:"price"`
+	Currency   string    `json:"currency"`
+	ObservedAt string    `json:"observed_at"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// История цен товара
+type PriceHistory struct {
+	Product      ProductIdentity    `json:"product"`
+	Observations []PriceObservation `json:"observations"`
+}
+
+// Категория каталога
+type Category struct {
+	Id       int      `json:"id"`
+	Name     string   `json:"name"`
+	ParentId *int     `json:"parent_id"`
+	Aliases  []string `json:"aliases"`
+}
+
+// Действие при загрузке товара с категорией, которой нет в каталоге
+type UnknownCategoryPolicy string
+
+const (
+	UnknownCategoryCreate     UnknownCategoryPolicy = "create"
+	UnknownCategoryReject     UnknownCategoryPolicy = "reject"
+	UnknownCategoryQuarantine UnknownCategoryPolicy = "quarantine"
+)
+
+// Курс валюты: сколько единиц базовой валюты стоит единица валюты на дату
+type ExchangeRate struct {
+	Currency string  `json:"currency"`
+	Date     string  `json:"date"`
+	Rate     float64 `json:"rate"`
+}
+
+// Представление товара в JSON
+type ProductJSON struct {
+	Id        int     `json:"id"`
+	Name      string  `json:"name"`
+	Category  string  `json:"category"`
+	Price     float64 `json:"price"`
+	Currency  string  `json:"currency"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// Результат поиска: товар и его релевантность
+type SearchResult struct {
+	ProductJSON
+	Rank float64 `json:"rank"`
+}
+
+// Право доступа API-ключа
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // выгрузка, статистика и просмотр
+	ScopeWrite Scope = "write" // загрузка, изменение и удаление
+	ScopeAdmin Scope = "admin" // каталог, карантин, курсы, дедупликация; включает read и write
+)
+
+// API-ключ без самого ключа: хранится только его хеш
+type APIKey struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []Scope    `json:"scopes"`
+	Tenant     string     `json:"tenant,omitempty"` // пустой — ключ не привязан к арендатору
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Клиент, выполнивший запрос, и его права
+type Principal struct {
+	Subject string // jwt:<sub> для токена, apikey:<имя> для API-ключа
+	Scopes  []Scope
+	Tenant  string // арендатор клиента, если клиент к нему привязан
+}
+
+// Результат одной проверки готовности
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Ответ проверки работоспособности или готовности
+type HealthResponse struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks,omitempty"`
+}
+
+// Статусы проверок
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+type GetPricesResponse struct {
+	TotalCount       int     `json:"total_count"`
+	DuplicatesCount  int     `json:"duplicates_count"`
+	TotalItems       int     `json:"total_items"`
+	TotalCategories  int     `json:"total_categories"`
+	TotalPrice       float64 `json:"total_price"`
+	QuarantinedCount int     `json:"quarantined_count"`
+}
+
+// Сведения о ранее обработанной загрузке
+type Upload struct {
+	IdempotencyKey string
+	ContentHash    string
+	Subject        string // кто загрузил, если включена проверка клиентов
+	Response       GetPricesResponse
+}
+
+// Строка, не прошедшая проверку при загрузке
+type QuarantinedRecord struct {
+	Id         int       `json:"id"`
+	RawRecord  []string  `json:"raw_record"`
+	SourceFile string    `json:"source_file"`
+	LineNumber int       `json:"line_number"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Исправления для строки из карантина; пустые поля берутся из исходной строки
+type QuarantineFix struct {
+	Id        *string `json:"id"`
+	Name      *string `json:"name"`
+	Category  *string `json:"category"`
+	Price     *string `json:"price"`
+	CreatedAt *string `json:"created_at"`
+	Sku       *string `json:"sku"`
+	Currency  *string `json:"currency"`
 }
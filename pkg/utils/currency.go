@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Код валюты ISO 4217
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Приводит код валюты к верхнему регистру и проверяет формат
+func NormalizeCurrency(value string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(value))
+	if !currencyPattern.MatchString(currency) {
+		return "", fmt.Errorf("неверный код валюты %q", value)
+	}
+	return currency, nil
+}
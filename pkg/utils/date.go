@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Форматы даты создания, принимаемые по умолчанию
+var DefaultDateLayouts = []string{
+	"2006-01-02",
+	"02.01.2006",
+	time.RFC3339,
+}
+
+// Разбирает дату по первому подходящему формату и отбрасывает время
+func ParseDate(value string, layouts []string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("дата %q не соответствует допустимым форматам", value)
+}
@@ -3,65 +3,43 @@ package utils
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
 
 	_ "github.com/lib/pq"
 )
 
 // Конфигурация подключения к базе данных
 type DBConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-}
-
-// Получает конфигурацию из переменных окружения
-func getDBConfig() DBConfig {
-	return DBConfig{
-		Host:     getEnvOrDefault("POSTGRES_HOST", "localhost"),
-		Port:     getEnvOrDefault("POSTGRES_PORT", "5432"),
-		User:     getEnvOrDefault("POSTGRES_USER", "validator"),
-		Password: getEnvOrDefault("POSTGRES_PASSWORD", "val1dat0r"),
-		DBName:   getEnvOrDefault("POSTGRES_DB", "project-sem-1"),
-	}
-}
-
-// Получает значение переменной окружения или возвращает значение по умолчанию
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+	Host     string `yaml:"host" env:"POSTGRES_HOST" flag:"db-host" usage:"адрес PostgreSQL"`
+	Port     string `yaml:"port" env:"POSTGRES_PORT" flag:"db-port" usage:"порт PostgreSQL"`
+	User     string `yaml:"user" env:"POSTGRES_USER" flag:"db-user" usage:"пользователь PostgreSQL"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD" flag:"db-password" usage:"пароль PostgreSQL" secret:"true"`
+	DBName   string `yaml:"dbname" env:"POSTGRES_DB" flag:"db-name" usage:"имя базы данных"`
+	SSLMode  string `yaml:"sslmode" env:"POSTGRES_SSLMODE" flag:"db-sslmode" usage:"режим SSL подключения"`
 }
 
 // Создает строку подключения из конфигурации
 func buildConnectionString(config DBConfig) string {
 	return fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		config.Host, config.Port, config.User, config.Password, config.DBName,
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode,
 	)
 }
 
-// Подключается к базе данных
-func ConnectDB() *sql.DB {
-	config := getDBConfig()
+// Подключается к базе данных и проверяет подключение
+func ConnectDB(config DBConfig) (*sql.DB, error) {
 	connStr := buildConnectionString(config)
 
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatalf("Не удалось подключиться к базе данных: %v", err)
+		return nil, fmt.Errorf("не удалось подключиться к базе данных: %w", err)
 	}
 
 	if err = db.Ping(); err != nil {
 		db.Close()
-		log.Fatalf("Не удалось проверить подключение к базе данных: %v", err)
+		return nil, fmt.Errorf("не удалось проверить подключение к базе данных: %w", err)
 	}
 
-	log.Println("Успешное подключение к базе данных")
-	return db
+	return db, nil
 }
 
 // Закрывает соединение с базой данных
@@ -3,6 +3,14 @@
 # Установка Go-зависимостей
 go mod tidy
 
+# Базовая валюта из конфигурации сервиса (значение по умолчанию, CONFIG_FILE, BASE_CURRENCY):
+# ею помечаются цены, загруженные до появления колонки currency
+BASE_CURRENCY=$(go run ./cmd/server -print-config | awk '$1 == "base_currency:" { print $2 }')
+if [ -z "$BASE_CURRENCY" ]; then
+    echo "Не удалось определить базовую валюту из конфигурации" >&2
+    exit 1
+fi
+
 # Создание таблицы
 PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
 CREATE TABLE IF NOT EXISTS prices (
@@ -12,3 +20,183 @@ CREATE TABLE IF NOT EXISTS prices (
     category TEXT,
     price NUMERIC
 );"
+
+# Создание таблицы обработанных загрузок (идемпотентность)
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+CREATE TABLE IF NOT EXISTS uploads (
+    id SERIAL PRIMARY KEY,
+    idempotency_key TEXT UNIQUE,
+    content_hash TEXT NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS uploads_content_hash_idx ON uploads (content_hash);"
+
+# Создание таблицы карантина для некорректных строк
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+CREATE TABLE IF NOT EXISTS quarantine (
+    id SERIAL PRIMARY KEY,
+    raw_record TEXT[] NOT NULL,
+    source_file TEXT NOT NULL,
+    line_number INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);"
+
+# Создание таблиц товаров и истории цен
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+CREATE TABLE IF NOT EXISTS products (
+    id SERIAL PRIMARY KEY,
+    sku TEXT UNIQUE,
+    name TEXT NOT NULL,
+    category TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS price_observations (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price_id INTEGER NOT NULL,
+    price NUMERIC NOT NULL,
+    observed_at DATE NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS price_observations_product_idx ON price_observations (product_id, observed_at);
+
+-- Переносим уже загруженные цены в историю при первом запуске
+INSERT INTO products (name, category)
+SELECT DISTINCT name, category FROM prices
+WHERE name IS NOT NULL AND category IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM products)
+ON CONFLICT DO NOTHING;
+INSERT INTO price_observations (product_id, price_id, price, observed_at)
+SELECT p.id, pr.id, pr.price, pr.created_at
+FROM prices pr
+JOIN products p ON p.name = pr.name AND p.category = pr.category AND p.sku IS NULL
+WHERE pr.price IS NOT NULL AND pr.created_at IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM price_observations);"
+
+# Создание каталога категорий
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    parent_id INTEGER REFERENCES categories (id) ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS categories_name_idx ON categories (lower(name));
+CREATE TABLE IF NOT EXISTS category_aliases (
+    alias TEXT PRIMARY KEY,
+    category_id INTEGER NOT NULL REFERENCES categories (id) ON DELETE CASCADE
+);
+
+-- Заполняем каталог категориями уже загруженных цен
+INSERT INTO categories (name)
+SELECT DISTINCT ON (lower(regexp_replace(btrim(category), '[[:space:]]+', ' ', 'g')))
+    regexp_replace(btrim(category), '[[:space:]]+', ' ', 'g')
+FROM prices
+WHERE btrim(category) <> ''
+ON CONFLICT DO NOTHING;"
+
+# Валюта цен и курсы валют
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '$BASE_CURRENCY';
+ALTER TABLE price_observations ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '$BASE_CURRENCY';
+-- Значение по умолчанию следует за настройкой при повторном запуске
+ALTER TABLE prices ALTER COLUMN currency SET DEFAULT '$BASE_CURRENCY';
+ALTER TABLE price_observations ALTER COLUMN currency SET DEFAULT '$BASE_CURRENCY';
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency TEXT NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (currency, rate_date)
+);"
+
+# Полнотекстовый поиск по названию и категории
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('russian', coalesce(name, '') || ' ' || coalesce(category, ''))
+    || to_tsvector('english', coalesce(name, '') || ' ' || coalesce(category, ''))
+) STORED;
+CREATE INDEX IF NOT EXISTS prices_search_vector_idx ON prices USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS prices_search_trgm_idx ON prices USING GIN ((coalesce(name, '') || ' ' || coalesce(category, '')) gin_trgm_ops);"
+
+# API-ключи: хранится только SHA-256 ключа
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL UNIQUE,
+    prefix TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);"
+
+# Кто выполнил загрузку (subject токена или имя API-ключа)
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS subject TEXT;"
+
+# Разделение данных по арендаторам: Id цен, ключи идемпотентности и товары уникальны в пределах арендатора.
+# Каталог категорий и курсы валют общие для всех арендаторов
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+ALTER TABLE prices ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE uploads ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE quarantine ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant TEXT;
+
+DO \$\$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.key_column_usage
+        WHERE table_name = 'prices' AND constraint_name = 'prices_pkey' AND column_name = 'tenant'
+    ) THEN
+        ALTER TABLE prices DROP CONSTRAINT prices_pkey;
+        ALTER TABLE prices ADD PRIMARY KEY (tenant, id);
+    END IF;
+END
+\$\$;
+
+ALTER TABLE uploads DROP CONSTRAINT IF EXISTS uploads_idempotency_key_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uploads_tenant_idempotency_key_idx ON uploads (tenant, idempotency_key);
+
+-- Уникальность товаров без арендатора из прежних версий схемы
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
+DROP INDEX IF EXISTS products_name_category_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS products_tenant_sku_idx ON products (tenant, sku);
+CREATE UNIQUE INDEX IF NOT EXISTS products_tenant_name_category_idx ON products (tenant, name, category) WHERE sku IS NULL;
+
+CREATE INDEX IF NOT EXISTS quarantine_tenant_idx ON quarantine (tenant, id);"
+
+# Приводим категории, сохраненные до каталога, к каноническим названиям: сравнение, как и при загрузке,
+# без учета регистра и лишних пробелов — сначала с названиями категорий, затем с синонимами.
+# Товары без артикула, совпавшие после замены, объединяются вместе с историей цен
+PGPASSWORD=val1dat0r psql -h localhost -p 5432 -U validator -d project-sem-1 -c "
+CREATE TEMP TABLE category_map AS
+SELECT legacy, canonical FROM (
+    SELECT v.legacy, COALESCE(
+        (SELECT name FROM categories WHERE lower(name) = v.normalized),
+        (SELECT c.name FROM category_aliases a JOIN categories c ON c.id = a.category_id WHERE a.alias = v.normalized)
+    ) AS canonical
+    FROM (
+        SELECT DISTINCT category AS legacy, lower(regexp_replace(btrim(category), '[[:space:]]+', ' ', 'g')) AS normalized
+        FROM (SELECT category FROM prices UNION SELECT category FROM products) categories_in_use
+        WHERE category IS NOT NULL
+    ) v
+) m
+WHERE canonical IS NOT NULL AND canonical <> legacy;
+
+UPDATE prices p SET category = m.canonical FROM category_map m WHERE p.category = m.legacy;
+UPDATE products p SET category = m.canonical FROM category_map m WHERE p.category = m.legacy AND p.sku IS NOT NULL;
+
+INSERT INTO products (tenant, name, category)
+SELECT DISTINCT p.tenant, p.name, m.canonical
+FROM products p JOIN category_map m ON p.category = m.legacy
+WHERE p.sku IS NULL
+ON CONFLICT DO NOTHING;
+UPDATE price_observations o SET product_id = t.id
+FROM products p
+JOIN category_map m ON p.category = m.legacy
+JOIN products t ON t.tenant = p.tenant AND t.name = p.name AND t.category = m.canonical AND t.sku IS NULL
+WHERE o.product_id = p.id AND p.sku IS NULL;
+DELETE FROM products p USING category_map m WHERE p.category = m.legacy AND p.sku IS NULL;"